@@ -0,0 +1,95 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// channelset.go contains the ordered set of the pubnub channels of the subscriptions.
+package pubnubMessaging
+
+import (
+    "strings"
+)
+
+// ChannelSet is an ordered set of pubnub channel names. The names are trimmed and the
+// empty names are ignored, each name is kept once in the order it was first added.
+// The zero value is an empty set ready to use. It is not safe for concurrent use.
+type ChannelSet struct {
+    channels   []string
+    index      map[string]int
+}
+
+// NewChannelSet creates a ChannelSet of the comma separated pubnub channels.
+func NewChannelSet(channels string) *ChannelSet {
+    set := &ChannelSet{}
+    for _, channel := range strings.Split(channels, ",") {
+        set.Add(channel)
+    }
+    return set
+}
+
+// Add adds the channel to the end of the set.
+//
+// returns true if the channel was added, false if it is empty or already in the set.
+func (set *ChannelSet) Add(channel string) bool {
+    channel = strings.TrimSpace(channel)
+    if((channel == "") || set.Contains(channel)){
+        return false
+    }
+    if(set.index == nil){
+        set.index = make(map[string]int)
+    }
+    set.index[channel] = len(set.channels)
+    set.channels = append(set.channels, channel)
+    return true
+}
+
+// Remove removes the channel from the set, keeping the order of the other channels.
+//
+// returns true if the channel was in the set.
+func (set *ChannelSet) Remove(channel string) bool {
+    channel = strings.TrimSpace(channel)
+    i, ok := set.index[channel]
+    if(!ok){
+        return false
+    }
+    set.channels = append(set.channels[:i:i], set.channels[i+1:]...)
+    delete(set.index, channel)
+    for j := i; j < len(set.channels); j++ {
+        set.index[set.channels[j]] = j
+    }
+    return true
+}
+
+// Contains returns true if the channel is in the set.
+func (set *ChannelSet) Contains(channel string) bool {
+    _, ok := set.index[strings.TrimSpace(channel)]
+    return ok
+}
+
+// Len returns the number of channels in the set.
+func (set *ChannelSet) Len() int {
+    return len(set.channels)
+}
+
+// Clear removes all the channels from the set.
+func (set *ChannelSet) Clear() {
+    set.channels = nil
+    set.index = nil
+}
+
+// Channels returns a copy of the channels in the set.
+func (set *ChannelSet) Channels() []string {
+    channels := make([]string, len(set.channels))
+    copy(channels, set.channels)
+    return channels
+}
+
+// Join returns the channels in the set as a comma separated string, each suffixed with the suffix.
+func (set *ChannelSet) Join(suffix string) string {
+    var joined []string
+    for _, channel := range set.channels {
+        joined = append(joined, channel + suffix)
+    }
+    return strings.Join(joined, ",")
+}
+
+// String returns the channels in the set as a comma separated string.
+func (set *ChannelSet) String() string {
+    return set.Join("")
+}
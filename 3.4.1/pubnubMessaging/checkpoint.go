@@ -0,0 +1,150 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// checkpoint.go contains the stores of the subscribe timetoken kept across the process restarts.
+package pubnubMessaging
+
+import (
+    "io/ioutil"
+    "os"
+    "strings"
+    "sync"
+    "time"
+)
+
+// CheckpointStore keeps the timetoken of the last messages delivered by the Subscribe/Presence
+// subscriptions of a Pubnub instance, set with the CheckpointStore of the Config.
+// The subscribe loop saves the timetoken after each response with messages and every CheckpointInterval,
+// and loads it when it starts so that the messages published while the process was stopped are received.
+//
+// The methods are called from the goroutine of the subscribe loop.
+type CheckpointStore interface {
+    // Load returns the saved timetoken, empty if none is saved.
+    Load() (string, error)
+
+    // Save saves the timetoken.
+    Save(timeToken string) error
+}
+
+// MemoryCheckpointStore is the CheckpointStore that keeps the timetoken in memory,
+// e.g. to share it between the instances of a process.
+type MemoryCheckpointStore struct {
+    lock        sync.Mutex
+    timeToken   string
+}
+
+// NewMemoryCheckpointStore creates a MemoryCheckpointStore without any timetoken.
+//
+// returns the pointer to the MemoryCheckpointStore.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+    return &MemoryCheckpointStore{}
+}
+
+// Load returns the saved timetoken, empty if none is saved.
+func (store *MemoryCheckpointStore) Load() (string, error) {
+    store.lock.Lock()
+    defer store.lock.Unlock()
+    return store.timeToken, nil
+}
+
+// Save keeps the timetoken.
+func (store *MemoryCheckpointStore) Save(timeToken string) error {
+    store.lock.Lock()
+    defer store.lock.Unlock()
+    store.timeToken = timeToken
+    return nil
+}
+
+// FileCheckpointStore is the CheckpointStore that keeps the timetoken in a file.
+// The file is replaced by a temporary file written next to it, so it is never left partially written.
+//
+// Path is the path of the file.
+type FileCheckpointStore struct {
+    Path   string
+    lock   sync.Mutex
+}
+
+// NewFileCheckpointStore creates a FileCheckpointStore, the file is created by the first Save.
+//
+// It accepts the following parameters:
+// path: the path of the file.
+//
+// returns the pointer to the FileCheckpointStore.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+    return &FileCheckpointStore{
+        Path:   path,
+    }
+}
+
+// Load returns the timetoken of the file, empty if the file doesn't exist.
+func (store *FileCheckpointStore) Load() (string, error) {
+    store.lock.Lock()
+    defer store.lock.Unlock()
+    contents, err := ioutil.ReadFile(store.Path)
+    if os.IsNotExist(err) {
+        return "", nil
+    }
+    if err != nil {
+        return "", err
+    }
+    return strings.TrimSpace(string(contents)), nil
+}
+
+// Save writes the timetoken to a temporary file, syncs it to the disk and renames it to the Path.
+func (store *FileCheckpointStore) Save(timeToken string) error {
+    store.lock.Lock()
+    defer store.lock.Unlock()
+    temporaryPath := store.Path + ".tmp"
+    file, err := os.OpenFile(temporaryPath, os.O_WRONLY | os.O_CREATE | os.O_TRUNC, 0600)
+    if err != nil {
+        return err
+    }
+    if _, err = file.WriteString(timeToken + "\n"); err == nil {
+        err = file.Sync()
+    }
+    if errClose := file.Close(); err == nil {
+        err = errClose
+    }
+    if err != nil {
+        os.Remove(temporaryPath)
+        return err
+    }
+    return os.Rename(temporaryPath, store.Path)
+}
+
+// LoadCheckpoint is the struct Pubnub's instance method that makes the next subscribe request be sent
+// with the timetoken of the CheckpointStore of the instance's Config, unless a timetoken was given
+// to Subscribe. It is called when the subscribe loop starts.
+func (pub *Pubnub) LoadCheckpoint() {
+    store := pub.config.CheckpointStore
+    if(store == nil){
+        return
+    }
+    timeToken, err := store.Load()
+    if err != nil {
+        pub.Log(LogLevelWarn, "checkpoint load failed", LogFields{Operation: OperationSubscribe, Err: err})
+        return
+    }
+    if((timeToken != "") && (timeToken != "0") && pub.subscription.resume(timeToken)){
+        pub.Log(LogLevelInfo, "resuming from checkpoint", LogFields{Operation: OperationSubscribe, Channel: pub.subscription.getChannels()})
+    }
+}
+
+// SaveCheckpoint is the struct Pubnub's instance method that saves the timetoken to the CheckpointStore
+// of the instance's Config. Unless force is true, the timetoken is saved only if the CheckpointInterval
+// elapsed since the last save.
+//
+// It accepts the following parameters:
+// timeToken: the timetoken of the messages delivered.
+// force: true to save the timetoken now, e.g. after a response with messages.
+func (pub *Pubnub) SaveCheckpoint(timeToken string, force bool) {
+    store := pub.config.CheckpointStore
+    if((store == nil) || (timeToken == "") || (timeToken == "0")){
+        return
+    }
+    now := time.Now()
+    if(!pub.subscription.checkpointDue(now, time.Duration(pub.config.CheckpointInterval) * time.Second, force)){
+        return
+    }
+    if err := store.Save(timeToken); err != nil {
+        pub.Log(LogLevelWarn, "checkpoint save failed", LogFields{Operation: OperationSubscribe, Err: err})
+    }
+}
@@ -0,0 +1,215 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// config.go contains the per instance configuration of the Pubnub client.
+package pubnubMessaging
+
+import (
+    "strings"
+    "time"
+    "net/http"
+    "crypto/tls"
+    "crypto/x509"
+)
+
+// Config holds the settings that tune the behavior of a single Pubnub instance, each instance
+// created with PubnubInitWithConfig keeps its own copy of it.
+// A Config should be created with DefaultConfig and then changed: the zero valued settings are
+// replaced by the defaults only when zero isn't a valid setting.
+type Config struct {
+    // Origin is the root url value of pubnub api without the http/https protocol.
+    Origin                   string
+    // Ssl is true if the requests should be sent over https.
+    Ssl                      bool
+    // ConnectTimeout is the HTTP transport Dial and TLS handshake timeout in seconds.
+    ConnectTimeout           int64
+    // SubscribeTimeout is the time in seconds after which the Subscribe/Presence request will timeout.
+    SubscribeTimeout         int64
+    // NonSubscribeTimeout is the time in seconds after which the Publish/HereNow/DetailedHistory/
+    // Unsubscribe/UnsubscribePresence/Time request will timeout.
+    NonSubscribeTimeout      int64
+    // MaxIdleConnsPerHost is the number of idle keep-alive connections to the origin kept for
+    // reuse by the non-subscribe requests.
+    MaxIdleConnsPerHost      int
+    // IdleConnTimeout is the time in seconds after which an idle keep-alive connection is closed.
+    IdleConnTimeout          int64
+    // PublishPostThreshold is the size in bytes of the serialized messages above which they are
+    // published by POST instead of in the url, negative to never choose POST automatically.
+    PublishPostThreshold     int
+    // MaxMessageSize is the limit in bytes of the size of the published messages as they are sent,
+    // see PublishSize. The larger messages fail with a MessageTooLargeError without being sent,
+    // negative for no limit.
+    MaxMessageSize           int
+    // MaxRetries is the number of reconnect attempts of the default RetryPolicy, UnlimitedRetries
+    // to never give up.
+    MaxRetries               int
+    // RetryInterval is the delay in seconds between the reconnect attempts of the default
+    // RetryPolicy, and the pause of the Subscribe/Presence loop after a timeout.
+    RetryInterval            int64
+    // RetryPolicy decides the delay before each reconnect attempt of the Subscribe/Presence
+    // subscriptions. If nil a LinearRetryPolicy of the RetryInterval and the MaxRetries is used.
+    RetryPolicy              RetryPolicy
+    // RequestRetryPolicy decides the delay before each retry of the Publish/HereNow/DetailedHistory/Time
+    // requests and the number of retries. If nil an ExponentialRetryPolicy of 3 retries is used.
+    RequestRetryPolicy       RetryPolicy
+    // RetryableErrors are the error categories, e.g. ErrorCategoryNetworkUnavailable, on which the
+    // requests of each operation, e.g. OperationPublish, are retried. If nil DefaultRetryableErrors
+    // is used, publish is then retried only if the request wasn't sent.
+    RetryableErrors          map[string][]string
+    // CheckpointStore, if set, keeps the timetoken of the messages delivered by the Subscribe/Presence
+    // subscriptions across the process restarts.
+    CheckpointStore          CheckpointStore
+    // CheckpointInterval is the time in seconds between the saves of the timetoken while no message
+    // is received, it is saved after each response with messages.
+    CheckpointInterval       int64
+    // ResumeOnReconnect: if true the last successfully retrieved timetoken is used upon reconnect,
+    // else a 0 (zero) timetoken is used and the messages missed while disconnected are skipped.
+    // The false of a Config{} is kept while DefaultConfig sets it to true.
+    ResumeOnReconnect        bool
+    // ProxyServer is the proxy server name or ip, empty if no proxy is used.
+    ProxyServer              string
+    // ProxyPort is the proxy port.
+    ProxyPort                int
+    // ProxyUser is the proxy user name.
+    ProxyUser                string
+    // ProxyPassword is the proxy password.
+    ProxyPassword            string
+    // HttpClient, if set, is used for all the requests of the instance instead of the built-in clients.
+    HttpClient               *http.Client
+    // Transport, if set and HttpClient is nil, is used as the http.RoundTripper of all the requests
+    // instead of the built-in transports. Proxy and dial settings of the Config are then left to it.
+    Transport                http.RoundTripper
+    // RootCAs is the pool used to verify the certificate of the origin, the system pool is used if nil.
+    RootCAs                  *x509.CertPool
+    // Certificates are the client certificates presented to the origin.
+    Certificates             []tls.Certificate
+    // PinnedPublicKeys, if set, are the base64 encoded SHA-256 hashes of the SubjectPublicKeyInfo
+    // (see PublicKeyPin) one of the certificates of the origin must match.
+    PinnedPublicKeys         []string
+    // InsecureSkipVerify disables the verification of the certificate of the origin. Use only for testing.
+    InsecureSkipVerify       bool
+    // Logger receives the log entries of the instance, if nil the entries are discarded by a NoopLogger.
+    Logger                   Logger
+    // Metrics receives the metrics of the instance, if nil the metrics are discarded by a
+    // NoopMetricsCollector.
+    Metrics                  MetricsCollector
+    // Interceptors run around each request of the instance in their order, the first one is the outermost.
+    Interceptors             []Interceptor
+    // Outbox, if set, saves the publish requests that fail before being sent and replays them,
+    // see NewOutbox. An Outbox is used by a single instance, it is ignored with an error log by
+    // the other instances created with it.
+    Outbox                   *Outbox
+}
+
+// DefaultConfig returns a Config populated with the package defaults.
+// The defaults can be changed with SetOrigin, SetSubscribeTimeout, SetResumeOnReconnect
+// and SetProxy, which only affect the instances created afterwards.
+//
+// returns the Config.
+func DefaultConfig() Config {
+    config := Config{
+        Origin:                 _origin,
+        Ssl:                    false,
+        ConnectTimeout:         _connectTimeout,
+        SubscribeTimeout:       _subscribeTimeout,
+        NonSubscribeTimeout:    _nonSubscribeTimeout,
+        MaxIdleConnsPerHost:    _maxIdleConnsPerHost,
+        IdleConnTimeout:        _idleConnTimeout,
+        PublishPostThreshold:   _publishPostThreshold,
+        MaxMessageSize:         _maxMessageSize,
+        MaxRetries:             _maxRetries,
+        RetryInterval:          _retryInterval,
+        CheckpointInterval:     _checkpointInterval,
+        ResumeOnReconnect:      _resumeOnReconnect,
+    }
+    if(_proxyServerEnabled){
+        config.ProxyServer = _proxyServer
+        config.ProxyPort = _proxyPort
+        config.ProxyUser = _proxyUser
+        config.ProxyPassword = _proxyPassword
+    }
+    return config
+}
+
+// ProxyEnabled returns true if a proxy server is set in the Config.
+func (config Config) ProxyEnabled() bool {
+    return strings.TrimSpace(config.ProxyServer) != ""
+}
+
+// clone returns a copy of the Config that shares no map or slice with it, the RetryableErrors,
+// Certificates, PinnedPublicKeys and Interceptors are copied.
+func (config Config) clone() Config {
+    if(config.RetryableErrors != nil){
+        retryableErrors := make(map[string][]string, len(config.RetryableErrors))
+        for operation, categories := range config.RetryableErrors {
+            retryableErrors[operation] = append([]string(nil), categories...)
+        }
+        config.RetryableErrors = retryableErrors
+    }
+    if(config.Certificates != nil){
+        config.Certificates = append([]tls.Certificate(nil), config.Certificates...)
+    }
+    if(config.PinnedPublicKeys != nil){
+        config.PinnedPublicKeys = append([]string(nil), config.PinnedPublicKeys...)
+    }
+    if(config.Interceptors != nil){
+        config.Interceptors = append([]Interceptor(nil), config.Interceptors...)
+    }
+    return config
+}
+
+// withDefaults returns a copy of the Config, see clone, where the zero valued
+// origin, timeouts, idle connection pool, publish POST threshold, message size limit, retry limits and checkpoint interval are replaced by the package defaults,
+// a negative MaxRetries, e.g. UnlimitedRetries, is kept,
+// a nil RetryPolicy by the LinearRetryPolicy of the RetryInterval and the MaxRetries, and a nil
+// RequestRetryPolicy, RetryableErrors, Logger and Metrics by their defaults.
+func (config Config) withDefaults() Config {
+    config = config.clone()
+    if(strings.TrimSpace(config.Origin) == ""){
+        config.Origin = _origin
+    }
+    if(config.ConnectTimeout <= 0){
+        config.ConnectTimeout = _connectTimeout
+    }
+    if(config.SubscribeTimeout <= 0){
+        config.SubscribeTimeout = _subscribeTimeout
+    }
+    if(config.NonSubscribeTimeout <= 0){
+        config.NonSubscribeTimeout = _nonSubscribeTimeout
+    }
+    if(config.MaxIdleConnsPerHost <= 0){
+        config.MaxIdleConnsPerHost = _maxIdleConnsPerHost
+    }
+    if(config.IdleConnTimeout <= 0){
+        config.IdleConnTimeout = _idleConnTimeout
+    }
+    if(config.PublishPostThreshold == 0){
+        config.PublishPostThreshold = _publishPostThreshold
+    }
+    if(config.MaxMessageSize == 0){
+        config.MaxMessageSize = _maxMessageSize
+    }
+    if(config.MaxRetries == 0){
+        config.MaxRetries = _maxRetries
+    }
+    if(config.RetryInterval <= 0){
+        config.RetryInterval = _retryInterval
+    }
+    if(config.CheckpointInterval <= 0){
+        config.CheckpointInterval = _checkpointInterval
+    }
+    if(config.RetryPolicy == nil){
+        config.RetryPolicy = NewLinearRetryPolicy(time.Duration(config.RetryInterval) * time.Second, config.MaxRetries)
+    }
+    if(config.RequestRetryPolicy == nil){
+        config.RequestRetryPolicy = NewExponentialRetryPolicy(_requestRetryMinDelay, _requestRetryMaxDelay, _requestMaxRetries)
+    }
+    if(config.RetryableErrors == nil){
+        config.RetryableErrors = DefaultRetryableErrors()
+    }
+    if(config.Logger == nil){
+        config.Logger = NoopLogger{}
+    }
+    if(config.Metrics == nil){
+        config.Metrics = NoopMetricsCollector{}
+    }
+    return config
+}
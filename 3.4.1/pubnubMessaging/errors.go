@@ -0,0 +1,456 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// errors.go contains the typed errors of the requests and their structured form sent on the error channels.
+package pubnubMessaging
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "strings"
+    "syscall"
+)
+
+// The categories of the errors in the structured form sent on the error channels.
+const (
+    ErrorCategoryNetworkUnavailable = "NetworkUnavailable"
+    ErrorCategoryTimeout = "Timeout"
+    ErrorCategoryAborted = "Aborted"
+    ErrorCategoryHttpStatus = "HttpStatus"
+    ErrorCategoryInvalidJson = "InvalidJson"
+    ErrorCategoryDecryptFailed = "DecryptFailed"
+    ErrorCategoryAccessDenied = "AccessDenied"
+    ErrorCategoryMessageTooLarge = "MessageTooLarge"
+    ErrorCategoryQueueFull = "QueueFull"
+    ErrorCategoryTls = "Tls"
+    ErrorCategoryError = "Error"
+)
+
+// ErrorCategoryConnectFailed is the category of the RetryableErrors matching the errors of a request
+// that failed before it was sent: the host couldn't be resolved or the connection couldn't be
+// established, see IsConnectError. The errors themselves are in the NetworkUnavailable or the
+// Timeout category.
+const ErrorCategoryConnectFailed = "ConnectFailed"
+
+// The operations of the requests, used in the structured form of the errors.
+const (
+    OperationPublish = "Publish"
+    OperationSubscribe = "Subscribe"
+    OperationLeave = "Leave"
+    OperationHistory = "History"
+    OperationHereNow = "HereNow"
+    OperationTime = "Time"
+)
+
+// NetworkUnavailableError is returned when the origin can't be reached,
+// e.g. the host can't be resolved, the connection can't be established or is reset.
+// Message is the description of the error, Err is the underlying error if any.
+type NetworkUnavailableError struct {
+    Message   string
+    Err       error
+}
+
+func (e *NetworkUnavailableError) Error() string {
+    if(e.Message == ""){
+        return _networkUnavailable
+    }
+    return e.Message
+}
+
+func (e *NetworkUnavailableError) Unwrap() error {
+    return e.Err
+}
+
+// TimeoutError is returned when the request didn't complete within the timeout of the instance's
+// Config or the deadline of its context. Err is the underlying error if any.
+type TimeoutError struct {
+    Err   error
+}
+
+func (e *TimeoutError) Error() string {
+    return _operationTimeout
+}
+
+func (e *TimeoutError) Unwrap() error {
+    return e.Err
+}
+
+// AbortedError is returned when the request was aborted by Abort, CloseExistingConnection
+// or the cancellation of its context. Err is the underlying error if any.
+type AbortedError struct {
+    Err   error
+}
+
+func (e *AbortedError) Error() string {
+    return _connectionAborted
+}
+
+func (e *AbortedError) Unwrap() error {
+    return e.Err
+}
+
+// HttpStatusError is returned when the origin responds with a non 200 status code.
+// StatusCode is the http status code, Message is the message of the response.
+type HttpStatusError struct {
+    StatusCode   int
+    Message      string
+}
+
+func (e *HttpStatusError) Error() string {
+    return fmt.Sprintf("%s, %d", e.Message, e.StatusCode)
+}
+
+// AccessDeniedError is returned when the origin responds with a 403 status code.
+// StatusCode is the http status code, Message is the message of the response.
+type AccessDeniedError struct {
+    StatusCode   int
+    Message      string
+}
+
+func (e *AccessDeniedError) Error() string {
+    return fmt.Sprintf("%s, %d", e.Message, e.StatusCode)
+}
+
+// InvalidJsonError is returned when the response can't be parsed. Err is the underlying error if any.
+type InvalidJsonError struct {
+    Err   error
+}
+
+func (e *InvalidJsonError) Error() string {
+    return _invalidJson
+}
+
+func (e *InvalidJsonError) Unwrap() error {
+    return e.Err
+}
+
+// DecryptError is returned when a message can't be decrypted with the cipher key.
+// Message is the description of the error.
+type DecryptError struct {
+    Message   string
+}
+
+func (e *DecryptError) Error() string {
+    return "Decrypt error: " + e.Message
+}
+
+// TlsError is returned when the TLS handshake with the origin fails, e.g. its certificate
+// can't be verified or doesn't match the pinned keys. Message is the description of the error,
+// Err is the underlying error if any.
+type TlsError struct {
+    Message   string
+    Err       error
+}
+
+func (e *TlsError) Error() string {
+    if(e.Message == ""){
+        return _tlsHandshakeFailed
+    }
+    return e.Message
+}
+
+func (e *TlsError) Unwrap() error {
+    return e.Err
+}
+
+// The message of the MessageTooLargeError, parsed back by DecodeErrorResponse.
+const _messageTooLargeFormat = "Message too large: %d bytes, the limit is %d bytes"
+
+// MessageTooLargeError is returned when the encoded size of a message is larger than the
+// MaxMessageSize of the Config, the message is not sent.
+// Size is the encoded size of the message in bytes, see MessageSize, MaxSize is the limit.
+type MessageTooLargeError struct {
+    Size      int
+    MaxSize   int
+}
+
+func (e *MessageTooLargeError) Error() string {
+    return fmt.Sprintf(_messageTooLargeFormat, e.Size, e.MaxSize)
+}
+
+// QueueFullError is returned when a message can't be queued by a Publisher because its queue is full,
+// or sent on its error channel for a message dropped from the full queue.
+type QueueFullError struct {
+}
+
+func (e *QueueFullError) Error() string {
+    return _publishQueueFull
+}
+
+// PubnubError is the structured form of the errors sent on the error channels.
+// Operation is the request that failed, e.g. Publish, Subscribe, History, HereNow, Time, Leave.
+// Channel is the pubnub channel of the request, empty if the request has none.
+// StatusCode is the http status code of the response, 0 if no response was received.
+// Err is the error, one of the typed errors of this file for the known failures.
+//
+// It is sent on the error channels as a json object, see DecodeErrorResponse.
+type PubnubError struct {
+    Operation    string
+    Channel      string
+    StatusCode   int
+    Err          error
+}
+
+func (e *PubnubError) Error() string {
+    return e.Err.Error()
+}
+
+func (e *PubnubError) Unwrap() error {
+    return e.Err
+}
+
+// errorResponse is the json form of a PubnubError.
+type errorResponse struct {
+    Operation    string `json:"operation"`
+    Channel      string `json:"channel,omitempty"`
+    StatusCode   int    `json:"statusCode,omitempty"`
+    Category     string `json:"category"`
+    Message      string `json:"message"`
+}
+
+// MarshalJSON encodes the PubnubError with the category and the message of its Err.
+func (e *PubnubError) MarshalJSON() ([]byte, error) {
+    response := errorResponse{
+        Operation:    e.Operation,
+        Channel:      e.Channel,
+        StatusCode:   e.StatusCode,
+        Category:     ErrorCategory(e.Err),
+        Message:      e.Err.Error(),
+    }
+    switch err := e.Err.(type) {
+        case *HttpStatusError:
+            response.Message = err.Message
+        case *AccessDeniedError:
+            response.Message = err.Message
+        case *DecryptError:
+            response.Message = err.Message
+    }
+    return json.Marshal(response)
+}
+
+// DecodeErrorResponse decodes a response received on an error channel into a PubnubError.
+// The Err of the PubnubError is the typed error of its category.
+//
+// It accepts the following parameters:
+// value: the response received on the error channel.
+//
+// returns the PubnubError,
+// error if the response is not a structured error.
+func DecodeErrorResponse(value []byte) (*PubnubError, error) {
+    var response errorResponse
+    if err := json.Unmarshal(value, &response); (err != nil) || (response.Category == "") {
+        return nil, &InvalidJsonError{Err: err}
+    }
+    pubnubError := &PubnubError{
+        Operation:    response.Operation,
+        Channel:      response.Channel,
+        StatusCode:   response.StatusCode,
+    }
+    switch response.Category {
+        case ErrorCategoryNetworkUnavailable:
+            pubnubError.Err = &NetworkUnavailableError{Message: response.Message}
+        case ErrorCategoryTimeout:
+            pubnubError.Err = &TimeoutError{}
+        case ErrorCategoryAborted:
+            pubnubError.Err = &AbortedError{}
+        case ErrorCategoryHttpStatus:
+            pubnubError.Err = &HttpStatusError{StatusCode: response.StatusCode, Message: response.Message}
+        case ErrorCategoryAccessDenied:
+            pubnubError.Err = &AccessDeniedError{StatusCode: response.StatusCode, Message: response.Message}
+        case ErrorCategoryInvalidJson:
+            pubnubError.Err = &InvalidJsonError{}
+        case ErrorCategoryDecryptFailed:
+            pubnubError.Err = &DecryptError{Message: response.Message}
+        case ErrorCategoryMessageTooLarge:
+            messageTooLargeError := &MessageTooLargeError{}
+            fmt.Sscanf(response.Message, _messageTooLargeFormat, &messageTooLargeError.Size, &messageTooLargeError.MaxSize)
+            pubnubError.Err = messageTooLargeError
+        case ErrorCategoryQueueFull:
+            pubnubError.Err = &QueueFullError{}
+        case ErrorCategoryTls:
+            pubnubError.Err = &TlsError{Message: response.Message}
+        default:
+            pubnubError.Err = errors.New(response.Message)
+    }
+    return pubnubError, nil
+}
+
+// ErrorCategory returns the category of the error, ErrorCategoryError if it is not one of the
+// typed errors of this file.
+func ErrorCategory(err error) string {
+    var networkUnavailableError *NetworkUnavailableError
+    var timeoutError *TimeoutError
+    var abortedError *AbortedError
+    var httpStatusError *HttpStatusError
+    var accessDeniedError *AccessDeniedError
+    var invalidJsonError *InvalidJsonError
+    var decryptError *DecryptError
+    var messageTooLargeError *MessageTooLargeError
+    var queueFullError *QueueFullError
+    var tlsError *TlsError
+    switch {
+        case errors.As(err, &networkUnavailableError):
+            return ErrorCategoryNetworkUnavailable
+        case errors.As(err, &timeoutError):
+            return ErrorCategoryTimeout
+        case errors.As(err, &abortedError):
+            return ErrorCategoryAborted
+        case errors.As(err, &httpStatusError):
+            return ErrorCategoryHttpStatus
+        case errors.As(err, &accessDeniedError):
+            return ErrorCategoryAccessDenied
+        case errors.As(err, &invalidJsonError):
+            return ErrorCategoryInvalidJson
+        case errors.As(err, &decryptError):
+            return ErrorCategoryDecryptFailed
+        case errors.As(err, &messageTooLargeError):
+            return ErrorCategoryMessageTooLarge
+        case errors.As(err, &queueFullError):
+            return ErrorCategoryQueueFull
+        case errors.As(err, &tlsError):
+            return ErrorCategoryTls
+    }
+    return ErrorCategoryError
+}
+
+// CreateStatusCodeError creates the typed error of a non 200 response, an AccessDeniedError
+// for 403, else an HttpStatusError.
+// The message of the response is used if the response is a pubnub error array or object.
+//
+// It accepts the following parameters:
+// message: the message used if the response has none.
+// statusCode: the http status code.
+// value: the response contents.
+//
+// returns the error.
+func CreateStatusCodeError(message string, statusCode int, value []byte) error {
+    var s interface{}
+    if errJson := json.Unmarshal(value, &s); errJson == nil {
+        switch vv := s.(type) {
+            case []interface{}:
+                if(len(vv) > 1){
+                    if responseMessage, ok := vv[1].(string); ok {
+                        message = responseMessage
+                    }
+                }
+            case map[string]interface{}:
+                if responseMessage, ok := vv["message"].(string); ok {
+                    message = responseMessage
+                }
+        }
+    }
+    if(statusCode == http.StatusForbidden){
+        return &AccessDeniedError{StatusCode: statusCode, Message: message}
+    }
+    return &HttpStatusError{StatusCode: statusCode, Message: message}
+}
+
+// ClassifyError maps the error of an http request to the typed errors of this file.
+// A host that can't be resolved, a connection that can't be established or is closed by the origin
+// before the response is a NetworkUnavailableError,
+// a failed TLS handshake or certificate verification is a TlsError.
+// The unknown errors are returned as is.
+//
+// It accepts the following parameters:
+// err: the error of the http request.
+//
+// returns the typed error.
+func ClassifyError(err error) error {
+    var networkUnavailableError *NetworkUnavailableError
+    var netError net.Error
+    if (IsTlsError(err)) {
+        return &TlsError{Message: fmt.Sprintf("%s: %s", _tlsHandshakeFailed, err.Error()), Err: err}
+    } else if (errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netError) && netError.Timeout())) {
+        return &TimeoutError{Err: err}
+    } else if (errors.Is(err, context.Canceled) || errors.Is(err, net.ErrClosed)) {
+        return &AbortedError{Err: err}
+    } else if (errors.As(err, &networkUnavailableError)) {
+        return networkUnavailableError
+    } else if (IsConnectError(err)) {
+        return &NetworkUnavailableError{Err: err}
+    } else if (errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+        return &NetworkUnavailableError{Message: _connectionResetByPeerU, Err: err}
+    }
+    return err
+}
+
+// IsConnectError checks if the request failed before it was sent to the origin, because the host
+// couldn't be resolved or the connection couldn't be established.
+//
+// It accepts the following parameters:
+// err: the error of the http request.
+//
+// returns true if the request wasn't sent.
+func IsConnectError(err error) bool {
+    var dnsError *net.DNSError
+    var opError *net.OpError
+    return errors.As(err, &dnsError) || (errors.As(err, &opError) && (opError.Op == "dial"))
+}
+
+// CreatePubnubError creates the PubnubError of the error of a request.
+//
+// It accepts the following parameters:
+// operation: the request that failed.
+// channel: the pubnub channel of the request, the "-pnpres" suffix is removed.
+// statusCode: the http status code of the response, 0 if none.
+// err: the error.
+//
+// returns the pointer to the PubnubError.
+func CreatePubnubError(operation string, channel string, statusCode int, err error) *PubnubError {
+    return &PubnubError{
+        Operation:    operation,
+        Channel:      strings.Replace(channel, _presenceSuffix, "", -1),
+        StatusCode:   statusCode,
+        Err:          err,
+    }
+}
+
+// CreateErrorResponse creates the json encoded PubnubError of the error of a request,
+// as sent on the error channels.
+//
+// It accepts the same parameters as CreatePubnubError.
+//
+// returns the json response,
+// error if the PubnubError can't be encoded.
+func CreateErrorResponse(operation string, channel string, statusCode int, err error) ([]byte, error) {
+    return json.Marshal(CreatePubnubError(operation, channel, statusCode, err))
+}
+
+// SendErrorToChannel is the struct Pubnub's instance method that sends the structured form of
+// the error, a json encoded PubnubError, to the error channel.
+//
+// It accepts the following parameters:
+// c: Channel on which to send the error. If nil the PubnubError of each of the pubnub channels is
+// sent to the Listeners of the channel and the Listeners added with AddListener that implement ErrorListener.
+// operation: the request that failed.
+// channels: Pubnub Channels of the request. Comma separated string for multiple channels,
+// can be empty.
+// statusCode: the http status code of the response, 0 if none.
+// err: the error.
+func (pub *Pubnub) SendErrorToChannel(c chan []byte, operation string, channels string, statusCode int, err error) {
+    channelArray := strings.Split(channels, ",")
+    for i := 0; i < len(channelArray); i++ {
+        channel := strings.TrimSpace(channelArray[i])
+        if((channel == "") && (len(channelArray) > 1)){
+            continue
+        }
+        pubnubError := CreatePubnubError(operation, channel, statusCode, err)
+        if(c != nil){
+            if value, errJson := json.Marshal(pubnubError); errJson == nil {
+                c <- value
+            }
+            continue
+        }
+        if(channel == ""){
+            continue
+        }
+        for _, l := range pub.GetListeners(channel) {
+            if errorListener, ok := l.(ErrorListener); ok {
+                errorListener.OnError(pubnubError)
+            }
+        }
+    }
+}
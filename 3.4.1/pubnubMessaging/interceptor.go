@@ -0,0 +1,127 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// interceptor.go contains the structured requests and the interceptor chain they are sent through.
+package pubnubMessaging
+
+import (
+    "context"
+    "net/url"
+    "strings"
+)
+
+// PubnubRequest is the structured form of a request sent to the pubnub origin,
+// received by the Interceptors before it is sent.
+//
+// Operation is the operation of the request, e.g. OperationPublish.
+// Path is the unescaped path of the request, without the origin.
+// Query are the query parameters of the request.
+// Channels are the pubnub channels of the request, the presence channels suffixed with "-pnpres".
+// IsSubscribe is true for the long-poll Subscribe/Presence requests, sent on the subscribe transport.
+// Method is the http method of the request, "GET" if empty.
+// Body is the json body of the request, nil if none.
+// DiscardResponseBody, if true, completes the request once the response headers are received,
+// the response contents are nil.
+type PubnubRequest struct {
+    Operation             string
+    Path                  string
+    Query                 url.Values
+    Channels              []string
+    IsSubscribe           bool
+    Method                string
+    Body                  []byte
+    DiscardResponseBody   bool
+}
+
+// RequestHandler sends a PubnubRequest and returns the response contents, the response code
+// and the error.
+type RequestHandler func(ctx context.Context, request *PubnubRequest) ([]byte, int, error)
+
+// Interceptor runs around the requests of a Pubnub instance, set with the Interceptors of the Config.
+// It can inspect or modify the request before calling next to send it, and inspect or modify
+// the response contents, the response code and the error returned by next before they are parsed.
+// It can also return without calling next, e.g. to inject a fault.
+//
+// The Interceptors are called for each attempt of a request, from any number of goroutines at once.
+type Interceptor func(ctx context.Context, request *PubnubRequest, next RequestHandler) ([]byte, int, error)
+
+// ParsePubnubRequest creates the PubnubRequest of a request url.
+//
+// It accepts the following parameters:
+// operation: the operation of the request, e.g. OperationPublish.
+// channels: comma separated pubnub channel list, can be empty.
+// requestUrl: the url of the request without the origin, e.g. the one returned by CreateHistoryUrl.
+// isSubscribe: true if it is a subscribe request.
+//
+// returns the pointer to the PubnubRequest,
+// error if the url can't be parsed.
+func ParsePubnubRequest(operation string, channels string, requestUrl string, isSubscribe bool) (*PubnubRequest, error) {
+    parsedUrl, err := url.Parse(requestUrl)
+    if err != nil {
+        return nil, err
+    }
+    request := &PubnubRequest{
+        Operation:     operation,
+        Path:          parsedUrl.Path,
+        Query:         parsedUrl.Query(),
+        IsSubscribe:   isSubscribe,
+    }
+    for _, channel := range strings.Split(channels, ",") {
+        if channel = strings.TrimSpace(channel); channel != "" {
+            request.Channels = append(request.Channels, channel)
+        }
+    }
+    return request, nil
+}
+
+// Url returns the url of the request without the origin, the path escaped and the query encoded.
+func (request *PubnubRequest) Url() string {
+    requestUrl := url.URL{Path: request.Path}
+    if(len(request.Query) > 0){
+        requestUrl.RawQuery = request.Query.Encode()
+    }
+    return requestUrl.String()
+}
+
+// SendRequest is the struct Pubnub's instance method that sends the request through the Interceptors
+// of the instance's Config, in their order, the first one is the outermost. The last one calls
+// HttpRequestWithBody, without reading the response body if the DiscardResponseBody of the request is true.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// request: the request to send.
+//
+// returns the response contents, the response code and the error, as returned by the first Interceptor.
+func (pub *Pubnub) SendRequest(ctx context.Context, request *PubnubRequest) ([]byte, int, error) {
+    handler := RequestHandler(func(ctx context.Context, request *PubnubRequest) ([]byte, int, error) {
+        method := request.Method
+        if(method == ""){
+            method = "GET"
+        }
+        return pub.httpRequest(ctx, method, request.Url(), request.Body, request.IsSubscribe, !request.DiscardResponseBody)
+    })
+    for i := len(pub.config.Interceptors) - 1; i >= 0; i-- {
+        interceptor, next := pub.config.Interceptors[i], handler
+        handler = func(ctx context.Context, request *PubnubRequest) ([]byte, int, error) {
+            return interceptor(ctx, request, next)
+        }
+    }
+    return handler(ctx, request)
+}
+
+// SendRequestUrl is the struct Pubnub's instance method that parses the request url with
+// ParsePubnubRequest and sends it with SendRequest.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// operation: the operation of the request, e.g. OperationPublish.
+// channels: comma separated pubnub channel list, can be empty.
+// requestUrl: the url of the request without the origin.
+// isSubscribe: true if it is a subscribe request.
+//
+// returns the response contents, the response code and the error.
+func (pub *Pubnub) SendRequestUrl(ctx context.Context, operation string, channels string, requestUrl string, isSubscribe bool) ([]byte, int, error) {
+    request, err := ParsePubnubRequest(operation, channels, requestUrl, isSubscribe)
+    if err != nil {
+        return nil, 0, err
+    }
+    return pub.SendRequest(ctx, request)
+}
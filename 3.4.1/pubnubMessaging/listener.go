@@ -0,0 +1,300 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// listener.go contains the listeners of the Subscribe/Presence subscriptions.
+package pubnubMessaging
+
+import (
+    "context"
+    "encoding/json"
+    "reflect"
+    "strconv"
+    "strings"
+)
+
+// Listener receives the messages, the presence events and the status events of the
+// Subscribe/Presence subscriptions of a Pubnub instance.
+//
+// A Listener added with AddListener receives the events of all the channels of the instance,
+// a Listener subscribed with SubscribeWithListener the events of its channels.
+// The methods are called from the subscribe loop and should return quickly.
+// The listeners are compared with ==, so the implementations should be pointers.
+type Listener interface {
+    OnMessage(message Message)
+    OnPresence(event Message)
+    OnStatus(event StatusEvent)
+}
+
+// ErrorListener is implemented by the Listeners that also receive the errors of the
+// Subscribe/Presence subscriptions.
+type ErrorListener interface {
+    OnError(err *PubnubError)
+}
+
+// ChannelListener is the Listener the chan based Subscribe and SubscribeMessages are adapted to.
+//
+// CallbackChannel receives the messages, the presence events and the status events as json.
+// MessageChannel, if not nil, receives the messages and the presence events as Message values
+// instead of the CallbackChannel.
+// ErrorChannel receives the errors and the Disconnected, MaxRetriesReached, Timeout and
+// AlreadySubscribed status events as json.
+type ChannelListener struct {
+    CallbackChannel   chan []byte
+    MessageChannel    chan Message
+    ErrorChannel      chan []byte
+}
+
+// OnMessage sends the message to the MessageChannel, or as json to the CallbackChannel.
+func (l *ChannelListener) OnMessage(message Message) {
+    if(l.MessageChannel != nil){
+        l.MessageChannel <- message
+    } else if(l.CallbackChannel != nil){
+        if value, err := CreateJsonResponse(message); err == nil {
+            l.CallbackChannel <- value
+        }
+    }
+}
+
+// OnPresence sends the presence event to the MessageChannel, or as json to the CallbackChannel.
+func (l *ChannelListener) OnPresence(event Message) {
+    l.OnMessage(event)
+}
+
+// OnStatus sends the json response of the status event for each of its channels to the
+// ErrorChannel or the CallbackChannel.
+func (l *ChannelListener) OnStatus(event StatusEvent) {
+    responseChannel := l.CallbackChannel
+    switch event.Category {
+        case StatusDisconnected, StatusMaxRetriesReached, StatusTimeout, StatusAlreadySubscribed:
+            responseChannel = l.ErrorChannel
+    }
+    if(responseChannel == nil){
+        return
+    }
+    for _, channel := range event.Channels {
+        responseChannel <- []byte(CreateStatusResponse(event.Category, channel, event.RetryCount))
+    }
+    for _, channel := range event.PresenceChannels {
+        responseChannel <- []byte(CreateStatusResponse(event.Category, channel + _presenceSuffix, event.RetryCount))
+    }
+}
+
+// OnError sends the structured form of the error to the ErrorChannel.
+func (l *ChannelListener) OnError(err *PubnubError) {
+    if(l.ErrorChannel == nil){
+        return
+    }
+    if value, errJson := json.Marshal(err); errJson == nil {
+        l.ErrorChannel <- value
+    }
+}
+
+// CreateJsonResponse creates the json response of a message sent on the callback channels,
+// the array of the message, the timetoken and the pubnub channel, e.g. [["hello"], "13796254500000001", "my_channel"].
+//
+// It accepts the following parameters:
+// message: the Message.
+//
+// returns the json response,
+// error if any.
+func CreateJsonResponse(message Message) ([]byte, error) {
+    response := []interface{} {[]interface{} {message.Data}, strconv.FormatInt(message.Timetoken, 10), message.Subscription}
+    jsonData, err := json.Marshal(response)
+    if (err != nil) {
+        return nil, err
+    }
+    return []byte(strings.Replace(string(jsonData), _presenceSuffix, "", -1)), nil
+}
+
+// AddListener is the struct Pubnub's instance method that adds a Listener receiving the events of
+// all the channels of the instance. Any number of listeners can be added.
+//
+// It accepts the following parameters:
+// listener: the Listener to add.
+func (pub *Pubnub) AddListener(listener Listener) {
+    pub.subscription.lock.Lock()
+    defer pub.subscription.lock.Unlock()
+    if(IndexOfListener(pub.subscription.listeners, listener) < 0){
+        pub.subscription.listeners = append(pub.subscription.listeners, listener)
+    }
+}
+
+// RemoveListener is the struct Pubnub's instance method that removes a Listener added with AddListener.
+//
+// It accepts the following parameters:
+// listener: the Listener to remove.
+func (pub *Pubnub) RemoveListener(listener Listener) {
+    pub.subscription.lock.Lock()
+    defer pub.subscription.lock.Unlock()
+    if i := IndexOfListener(pub.subscription.listeners, listener); i >= 0 {
+        pub.subscription.listeners = append(pub.subscription.listeners[:i], pub.subscription.listeners[i+1:]...)
+    }
+}
+
+// AddChannelListener is the struct Pubnub's instance method that adds a Listener of the subscription.
+// A ChannelListener replaces the ChannelListener of the subscription with the same CallbackChannel.
+//
+// It accepts the following parameters:
+// subscription: the pubnub channel, suffixed with "-pnpres" for a presence subscription.
+// listener: the Listener to add.
+func (pub *Pubnub) AddChannelListener(subscription string, listener Listener) {
+    pub.subscription.lock.Lock()
+    defer pub.subscription.lock.Unlock()
+    listeners := pub.subscription.channelListeners[subscription]
+    if channelListener, ok := listener.(*ChannelListener); ok {
+        for i, l := range listeners {
+            if existing, ok := l.(*ChannelListener); ok && (existing.CallbackChannel == channelListener.CallbackChannel) {
+                listeners[i] = listener
+                return
+            }
+        }
+    }
+    if(IndexOfListener(listeners, listener) < 0){
+        pub.subscription.channelListeners[subscription] = append(listeners, listener)
+    }
+}
+
+// RemoveChannelListener is the struct Pubnub's instance method that removes a Listener of the subscription.
+//
+// It accepts the following parameters:
+// subscription: the pubnub channel, suffixed with "-pnpres" for a presence subscription.
+// listener: the Listener to remove.
+//
+// returns true if the listener was found,
+// the number of the remaining listeners of the subscription.
+func (pub *Pubnub) RemoveChannelListener(subscription string, listener Listener) (bool, int) {
+    pub.subscription.lock.Lock()
+    defer pub.subscription.lock.Unlock()
+    listeners := pub.subscription.channelListeners[subscription]
+    i := IndexOfListener(listeners, listener)
+    if(i < 0){
+        return false, len(listeners)
+    }
+    listeners = append(listeners[:i:i], listeners[i+1:]...)
+    if(len(listeners) == 0){
+        delete(pub.subscription.channelListeners, subscription)
+    } else {
+        pub.subscription.channelListeners[subscription] = listeners
+    }
+    return true, len(listeners)
+}
+
+// RemoveChannelListeners is the struct Pubnub's instance method that removes all the Listeners of the subscription.
+//
+// It accepts the following parameters:
+// subscription: the pubnub channel, suffixed with "-pnpres" for a presence subscription.
+func (pub *Pubnub) RemoveChannelListeners(subscription string) {
+    pub.subscription.lock.Lock()
+    defer pub.subscription.lock.Unlock()
+    delete(pub.subscription.channelListeners, subscription)
+}
+
+// GetListeners is the struct Pubnub's instance method that returns the Listeners of the subscription
+// followed by the Listeners added with AddListener. Returns only the latter if the subscription is empty.
+//
+// It accepts the following parameters:
+// subscription: the pubnub channel, suffixed with "-pnpres" for a presence subscription.
+//
+// returns the listeners.
+func (pub *Pubnub) GetListeners(subscription string) []Listener {
+    pub.subscription.lock.Lock()
+    defer pub.subscription.lock.Unlock()
+    var listeners []Listener
+    if(subscription != ""){
+        listeners = append(listeners, pub.subscription.channelListeners[subscription]...)
+    }
+    for _, l := range pub.subscription.listeners {
+        if(IndexOfListener(listeners, l) < 0){
+            listeners = append(listeners, l)
+        }
+    }
+    return listeners
+}
+
+// IndexOfListener returns the index of the listener in the listeners, -1 if not found.
+func IndexOfListener(listeners []Listener, listener Listener) int {
+    if((listener == nil) || !reflect.TypeOf(listener).Comparable()){
+        return -1
+    }
+    for i, l := range listeners {
+        if(reflect.TypeOf(l) == reflect.TypeOf(listener) && l == listener){
+            return i
+        }
+    }
+    return -1
+}
+
+// GetChannelForPubnubChannel is the struct Pubnub's instance method that returns the callback or
+// the error channel of the first ChannelListener of the pubnub channel.
+//
+// Accepts the pubnub channel name channel as string, suffixed with "-pnpres" for a Presence
+// subscription, and isErrorChannel as bool. If it is true the ErrorChannel of the ChannelListener
+// is returned, else its CallbackChannel.
+//
+// Returns channel to send a response on, nil if the channel has no ChannelListener,
+// and bool if true means it is a pubnub Presence channel. Else it is a pubnub Subscribe channel.
+func (pub *Pubnub) GetChannelForPubnubChannel(channel string, isErrorChannel bool) (chan []byte, bool) {
+    channel = strings.TrimSpace(channel)
+    isPresence := strings.HasSuffix(channel, _presenceSuffix)
+    for _, l := range pub.GetListeners(channel) {
+        if channelListener, ok := l.(*ChannelListener); ok {
+            if(isErrorChannel){
+                return channelListener.ErrorChannel, isPresence
+            }
+            return channelListener.CallbackChannel, isPresence
+        }
+    }
+    return nil, isPresence
+}
+
+// SendJsonResponse is the struct Pubnub's instance method that creates the json response of a
+// message and sends it as is to the callback channels of the ChannelListeners of the pubnub channel.
+//
+// Accepts:
+// message: response to send back,
+// returnTimeToken: the timetoken for the response,
+// channelName: the pubnub channel for the response.
+func (pub *Pubnub) SendJsonResponse(message interface{}, returnTimeToken string, channelName string) {
+    if(channelName == ""){
+        return
+    }
+    jsonData, err := json.Marshal([]interface{} {message, returnTimeToken, channelName})
+    if(err != nil){
+        pub.SendErrorToChannel(nil, OperationSubscribe, channelName, 0, &InvalidJsonError{Err: err})
+        return
+    }
+    pub.SendRawResponseToChannel(nil, channelName, string(jsonData))
+}
+
+// SubscribeWithListener is the struct Pubnub's instance method that subscribes to the channels
+// and adds the listener as a Listener of each of them.
+// The listener can be nil, the events are then received only by the Listeners added with AddListener.
+//
+// It accepts the following parameters:
+// channels: comma separated pubnub channel list.
+// timetoken: if timetoken is present the subscribe request is sent using this timetoken
+// listener: the Listener of the channels, can be nil.
+// isPresenceSubscribe: tells the method that presence subscription is requested.
+//
+// returns error if any of the channels is invalid.
+func (pub *Pubnub) SubscribeWithListener(channels string, timetoken string, listener Listener, isPresenceSubscribe bool) error {
+    return pub.SubscribeWithListenerWithContext(context.Background(), channels, timetoken, listener, isPresenceSubscribe)
+}
+
+// SubscribeWithListenerWithContext is the context aware variant of SubscribeWithListener.
+// When the ctx is done the listener is removed from the channels, the channels without any
+// other Listener are removed from the subscription.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscription.
+// channels: comma separated pubnub channel list.
+// timetoken: if timetoken is present the subscribe request is sent using this timetoken
+// listener: the Listener of the channels, can be nil.
+// isPresenceSubscribe: tells the method that presence subscription is requested.
+//
+// returns error if any of the channels is invalid.
+func (pub *Pubnub) SubscribeWithListenerWithContext(ctx context.Context, channels string, timetoken string, listener Listener, isPresenceSubscribe bool) error {
+    if err := ValidateChannel(channels); err != nil {
+        return err
+    }
+    pub.ExecuteSubscribe(ctx, channels, timetoken, listener, isPresenceSubscribe)
+    return nil
+}
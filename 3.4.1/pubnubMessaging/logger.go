@@ -0,0 +1,143 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// logger.go contains the pluggable structured logger of the Pubnub instances.
+package pubnubMessaging
+
+import (
+    "encoding/json"
+    "io"
+    "sync"
+    "time"
+)
+
+// LogLevel is the severity of a log entry.
+type LogLevel int
+
+// The levels of the log entries, in increasing severity.
+const (
+    LogLevelDebug LogLevel = iota
+    LogLevelInfo
+    LogLevelWarn
+    LogLevelError
+)
+
+// String returns the name of the level.
+func (level LogLevel) String() string {
+    switch level {
+        case LogLevelDebug:
+            return "debug"
+        case LogLevelInfo:
+            return "info"
+        case LogLevelWarn:
+            return "warn"
+        case LogLevelError:
+            return "error"
+    }
+    return "unknown"
+}
+
+// LogFields are the structured fields of a log entry, the fields that don't apply
+// to the entry are left to their zero values.
+//
+// Operation is the operation of the request, e.g. OperationPublish.
+// Channel is the pubnub channel or the comma separated channels of the request.
+// StatusCode is the http status code of the response.
+// Duration is the duration of the request, or the delay before a retry or a reconnect.
+// RetryCount is the number of retries or reconnect attempts made so far.
+// Err is the error of the request, nil if it succeeded.
+type LogFields struct {
+    Operation    string
+    Channel      string
+    StatusCode   int
+    Duration     time.Duration
+    RetryCount   int
+    Err          error
+}
+
+// Logger receives the log entries of a Pubnub instance, set with the Logger of the Config.
+// The library never writes to stdout, the entries are only sent to the Logger.
+//
+// Log can be called from any number of goroutines at once.
+type Logger interface {
+    Log(level LogLevel, message string, fields LogFields)
+}
+
+// NoopLogger is the default Logger, it discards all the entries.
+type NoopLogger struct{}
+
+// Log discards the entry.
+func (NoopLogger) Log(level LogLevel, message string, fields LogFields) {}
+
+// JsonLogger is the Logger that writes each entry at or above its Level as a json object
+// on a single line to the Writer.
+//
+// Writer is where the entries are written.
+// Level is the lowest level of the entries written.
+type JsonLogger struct {
+    Writer   io.Writer
+    Level    LogLevel
+    lock     sync.Mutex
+}
+
+// jsonLogEntry is the json form of an entry written by the JsonLogger.
+type jsonLogEntry struct {
+    Time         string  `json:"time"`
+    Level        string  `json:"level"`
+    Message      string  `json:"message"`
+    Operation    string  `json:"operation,omitempty"`
+    Channel      string  `json:"channel,omitempty"`
+    StatusCode   int     `json:"statusCode,omitempty"`
+    DurationMs   float64 `json:"durationMs,omitempty"`
+    RetryCount   int     `json:"retryCount,omitempty"`
+    Error        string  `json:"error,omitempty"`
+}
+
+// NewJsonLogger creates a JsonLogger.
+//
+// It accepts the following parameters:
+// writer: where the entries are written.
+// level: the lowest level of the entries written.
+//
+// returns the pointer to the JsonLogger.
+func NewJsonLogger(writer io.Writer, level LogLevel) *JsonLogger {
+    return &JsonLogger{
+        Writer:   writer,
+        Level:    level,
+    }
+}
+
+// Log writes the entry if its level is at or above the Level of the logger.
+func (logger *JsonLogger) Log(level LogLevel, message string, fields LogFields) {
+    if(level < logger.Level){
+        return
+    }
+    entry := jsonLogEntry{
+        Time:         time.Now().UTC().Format(time.RFC3339Nano),
+        Level:        level.String(),
+        Message:      message,
+        Operation:    fields.Operation,
+        Channel:      fields.Channel,
+        StatusCode:   fields.StatusCode,
+        DurationMs:   float64(fields.Duration) / float64(time.Millisecond),
+        RetryCount:   fields.RetryCount,
+    }
+    if(fields.Err != nil){
+        entry.Error = fields.Err.Error()
+    }
+    line, err := json.Marshal(entry)
+    if err != nil {
+        return
+    }
+    logger.lock.Lock()
+    defer logger.lock.Unlock()
+    logger.Writer.Write(append(line, '\n'))
+}
+
+// Log is the struct Pubnub's instance method that sends an entry to the Logger of the instance's Config.
+//
+// It accepts the following parameters:
+// level: the level of the entry.
+// message: the message of the entry.
+// fields: the structured fields of the entry.
+func (pub *Pubnub) Log(level LogLevel, message string, fields LogFields) {
+    pub.config.Logger.Log(level, message, fields)
+}
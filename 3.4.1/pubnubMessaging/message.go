@@ -0,0 +1,121 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// message.go contains the typed envelope of the messages received on a subscription.
+package pubnubMessaging
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "strings"
+)
+
+// Message is the typed envelope of a message or a presence event received on a
+// Subscribe/Presence subscription.
+//
+// Channel is the pubnub channel of the message, without the "-pnpres" suffix.
+// Subscription is the subscribed pubnub channel the message was received on, suffixed with
+// "-pnpres" for presence events.
+// Timetoken is the timetoken of the subscribe response that delivered the message.
+// Payload is the decrypted message as raw json.
+// Data is the decrypted message decoded into a string, float64, bool, nil,
+// []interface{} or map[string]interface{}.
+// PublisherUuid is the uuid of the user that triggered a presence event. It is empty for
+// the messages as the subscribe response doesn't carry the uuid of the publisher.
+// IsPresence is true for presence events.
+type Message struct {
+    Channel         string
+    Subscription    string
+    Timetoken       int64
+    Payload         json.RawMessage
+    Data            interface{}
+    PublisherUuid   string
+    IsPresence      bool
+}
+
+// CreateMessage creates the Message envelope of a decrypted message.
+//
+// It accepts the following parameters:
+// data: the decrypted message.
+// returnTimeToken: the timetoken of the subscribe response.
+// subscription: the subscribed pubnub channel the message was received on.
+//
+// returns the Message.
+func CreateMessage(data interface{}, returnTimeToken string, subscription string) Message {
+    subscription = strings.TrimSpace(subscription)
+    message := Message{
+        Channel:        strings.Replace(subscription, _presenceSuffix, "", -1),
+        Subscription:   subscription,
+        Timetoken:      ParseTimetoken(returnTimeToken),
+        Data:           data,
+        IsPresence:     strings.HasSuffix(subscription, _presenceSuffix),
+    }
+    if payload, err := json.Marshal(data); err == nil {
+        message.Payload = payload
+    }
+    if(message.IsPresence){
+        if event, ok := data.(map[string]interface{}); ok {
+            if uuid, ok := event["uuid"].(string); ok {
+                message.PublisherUuid = uuid
+            }
+        }
+    }
+    return message
+}
+
+// SendMessage is the struct Pubnub's instance method that sends a Message to the Listeners of its
+// subscription and the Listeners added with AddListener, to OnPresence for the presence events
+// else to OnMessage. The message is reported to the MetricsCollector of the instance's Config.
+//
+// It accepts the following parameters:
+// message: the Message to send.
+func (pub *Pubnub) SendMessage(message Message) {
+    pub.config.Metrics.MessageReceived(message.Subscription)
+    for _, listener := range pub.GetListeners(message.Subscription) {
+        if(message.IsPresence){
+            listener.OnPresence(message)
+        } else {
+            listener.OnMessage(message)
+        }
+    }
+}
+
+// SubscribeMessages is the variant of Subscribe that delivers the messages as typed Message values
+// on the messageChannel, using a ChannelListener. The connect, reconnect and the other status responses are still sent as
+// json on the callbackChannel.
+//
+// It accepts the following parameters:
+// channels: comma separated pubnub channel list.
+// timetoken: if timetoken is present the subscribe request is sent using this timetoken
+// callbackChannel: Channel on which to send the status responses back.
+// messageChannel: Channel on which to send the messages.
+// isPresenceSubscribe: tells the method that presence subscription is requested.
+// errorChannel: channel to send an error response to.
+func (pub *Pubnub) SubscribeMessages(channels string, timetoken string, callbackChannel chan []byte, messageChannel chan Message, isPresenceSubscribe bool, errorChannel chan []byte) {
+    pub.SubscribeMessagesWithContext(context.Background(), channels, timetoken, callbackChannel, messageChannel, isPresenceSubscribe, errorChannel)
+}
+
+// SubscribeMessagesWithContext is the context aware variant of SubscribeMessages.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscription.
+// channels: comma separated pubnub channel list.
+// timetoken: if timetoken is present the subscribe request is sent using this timetoken
+// callbackChannel: Channel on which to send the status responses back.
+// messageChannel: Channel on which to send the messages.
+// isPresenceSubscribe: tells the method that presence subscription is requested.
+// errorChannel: channel to send an error response to.
+func (pub *Pubnub) SubscribeMessagesWithContext(ctx context.Context, channels string, timetoken string, callbackChannel chan []byte, messageChannel chan Message, isPresenceSubscribe bool, errorChannel chan []byte) {
+    if(messageChannel == nil){
+        pub.SendErrorToChannel(errorChannel, OperationSubscribe, "", 0, fmt.Errorf("Message channel is nil"))
+        return
+    }
+    if(InvalidChannel(channels, callbackChannel)){
+        return 
+    }
+    listener := &ChannelListener{
+        CallbackChannel:   callbackChannel,
+        MessageChannel:    messageChannel,
+        ErrorChannel:      errorChannel,
+    }
+    pub.ExecuteSubscribe(ctx, channels, timetoken, listener, isPresenceSubscribe)
+}
@@ -0,0 +1,257 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// metrics.go contains the metrics hooks of the requests, the retries and the messages.
+package pubnubMessaging
+
+import (
+    "sync"
+    "time"
+)
+
+// MetricsCollector receives the metrics of a Pubnub instance, set with the Metrics of the Config.
+//
+// The methods are called from the goroutines of the requests and the subscribe loop, so they
+// can be called from any number of goroutines at once and should return quickly.
+type MetricsCollector interface {
+    // RequestCompleted is called at the end of each request, including each retry.
+    // statusCode is 0 if no response was received, err is nil if the request succeeded.
+    RequestCompleted(operation string, statusCode int, duration time.Duration, err error)
+
+    // RequestRetried is called before each retry of a Publish/HereNow/DetailedHistory/Time request,
+    // the retries are counted from 1.
+    RequestRetried(operation string, retryCount int)
+
+    // ReconnectAttempted is called before each reconnect attempt of the Subscribe/Presence
+    // subscriptions with the retry count of CheckForTimeoutAndRetries.
+    ReconnectAttempted(retryCount int)
+
+    // Reconnected is called when the Subscribe/Presence subscriptions reconnect after
+    // retryCount attempts.
+    Reconnected(retryCount int)
+
+    // MessageReceived is called for each message sent to the Listeners of the subscription.
+    MessageReceived(subscription string)
+
+    // MessagePublished is called for each message published successfully.
+    MessagePublished(channel string)
+
+    // DecryptFailed is called for each message that can't be decrypted with the cipher key.
+    DecryptFailed(operation string, channel string)
+}
+
+// NoopMetricsCollector is the default MetricsCollector, it discards all the metrics.
+type NoopMetricsCollector struct{}
+
+// RequestCompleted discards the metric.
+func (NoopMetricsCollector) RequestCompleted(operation string, statusCode int, duration time.Duration, err error) {}
+
+// RequestRetried discards the metric.
+func (NoopMetricsCollector) RequestRetried(operation string, retryCount int) {}
+
+// ReconnectAttempted discards the metric.
+func (NoopMetricsCollector) ReconnectAttempted(retryCount int) {}
+
+// Reconnected discards the metric.
+func (NoopMetricsCollector) Reconnected(retryCount int) {}
+
+// MessageReceived discards the metric.
+func (NoopMetricsCollector) MessageReceived(subscription string) {}
+
+// MessagePublished discards the metric.
+func (NoopMetricsCollector) MessagePublished(channel string) {}
+
+// DecryptFailed discards the metric.
+func (NoopMetricsCollector) DecryptFailed(operation string, channel string) {}
+
+// OperationMetrics are the metrics of the requests of an operation.
+//
+// Requests is the number of requests sent, including the retries.
+// Errors is the number of requests that failed.
+// Retries is the number of retries.
+// TotalLatency is the sum of the durations of the requests, MaxLatency the longest one.
+// StatusCodes is the number of responses of each http status code.
+type OperationMetrics struct {
+    Requests       int
+    Errors         int
+    Retries        int
+    TotalLatency   time.Duration
+    MaxLatency     time.Duration
+    StatusCodes    map[int]int
+}
+
+// AverageLatency returns the average duration of the requests, 0 if none was sent.
+func (metrics OperationMetrics) AverageLatency() time.Duration {
+    if(metrics.Requests == 0){
+        return 0
+    }
+    return metrics.TotalLatency / time.Duration(metrics.Requests)
+}
+
+// MetricsSnapshot is a copy of the metrics collected by an InMemoryMetrics.
+//
+// Operations are the metrics of the requests of each operation, e.g. OperationPublish.
+// ReconnectAttempts is the number of reconnect attempts, MaxRetryCount the highest retry count
+// reached and Reconnects the number of successful reconnects.
+// MessagesReceived is the number of messages received on each subscription, the presence
+// subscriptions suffixed with "-pnpres".
+// MessagesPublished is the number of messages published on each channel.
+// DecryptFailures is the number of messages of each channel that couldn't be decrypted.
+type MetricsSnapshot struct {
+    Operations          map[string]OperationMetrics
+    ReconnectAttempts   int
+    MaxRetryCount       int
+    Reconnects          int
+    MessagesReceived    map[string]int
+    MessagesPublished   map[string]int
+    DecryptFailures     map[string]int
+}
+
+// InMemoryMetrics is the MetricsCollector that keeps the metrics in memory,
+// read with Snapshot.
+type InMemoryMetrics struct {
+    lock       sync.Mutex
+    metrics    MetricsSnapshot
+}
+
+// NewInMemoryMetrics creates an InMemoryMetrics without any metric.
+//
+// returns the pointer to the InMemoryMetrics.
+func NewInMemoryMetrics() *InMemoryMetrics {
+    inMemoryMetrics := &InMemoryMetrics{}
+    inMemoryMetrics.Reset()
+    return inMemoryMetrics
+}
+
+// Reset discards all the metrics collected so far.
+func (m *InMemoryMetrics) Reset() {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    m.metrics = MetricsSnapshot{
+        Operations:          make(map[string]OperationMetrics),
+        MessagesReceived:    make(map[string]int),
+        MessagesPublished:   make(map[string]int),
+        DecryptFailures:     make(map[string]int),
+    }
+}
+
+// Snapshot returns a copy of the metrics collected so far.
+func (m *InMemoryMetrics) Snapshot() MetricsSnapshot {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    snapshot := m.metrics
+    snapshot.Operations = make(map[string]OperationMetrics, len(m.metrics.Operations))
+    for operation, metrics := range m.metrics.Operations {
+        statusCodes := make(map[int]int, len(metrics.StatusCodes))
+        for statusCode, count := range metrics.StatusCodes {
+            statusCodes[statusCode] = count
+        }
+        metrics.StatusCodes = statusCodes
+        snapshot.Operations[operation] = metrics
+    }
+    snapshot.MessagesReceived = copyCounts(m.metrics.MessagesReceived)
+    snapshot.MessagesPublished = copyCounts(m.metrics.MessagesPublished)
+    snapshot.DecryptFailures = copyCounts(m.metrics.DecryptFailures)
+    return snapshot
+}
+
+// copyCounts returns a copy of the counts.
+func copyCounts(counts map[string]int) map[string]int {
+    copied := make(map[string]int, len(counts))
+    for key, count := range counts {
+        copied[key] = count
+    }
+    return copied
+}
+
+// RequestCompleted counts the request, its status code, its latency and its error.
+func (m *InMemoryMetrics) RequestCompleted(operation string, statusCode int, duration time.Duration, err error) {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    metrics := m.metrics.Operations[operation]
+    if(metrics.StatusCodes == nil){
+        metrics.StatusCodes = make(map[int]int)
+    }
+    metrics.Requests++
+    if(err != nil){
+        metrics.Errors++
+    }
+    if(statusCode > 0){
+        metrics.StatusCodes[statusCode]++
+    }
+    metrics.TotalLatency += duration
+    if(duration > metrics.MaxLatency){
+        metrics.MaxLatency = duration
+    }
+    m.metrics.Operations[operation] = metrics
+}
+
+// RequestRetried counts the retry of the operation.
+func (m *InMemoryMetrics) RequestRetried(operation string, retryCount int) {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    metrics := m.metrics.Operations[operation]
+    metrics.Retries++
+    m.metrics.Operations[operation] = metrics
+}
+
+// ReconnectAttempted counts the reconnect attempt and keeps the highest retry count.
+func (m *InMemoryMetrics) ReconnectAttempted(retryCount int) {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    m.metrics.ReconnectAttempts++
+    if(retryCount > m.metrics.MaxRetryCount){
+        m.metrics.MaxRetryCount = retryCount
+    }
+}
+
+// Reconnected counts the reconnect.
+func (m *InMemoryMetrics) Reconnected(retryCount int) {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    m.metrics.Reconnects++
+}
+
+// MessageReceived counts the message of the subscription.
+func (m *InMemoryMetrics) MessageReceived(subscription string) {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    m.metrics.MessagesReceived[subscription]++
+}
+
+// MessagePublished counts the message published on the channel.
+func (m *InMemoryMetrics) MessagePublished(channel string) {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    m.metrics.MessagesPublished[channel]++
+}
+
+// DecryptFailed counts the decrypt failure of the channel.
+func (m *InMemoryMetrics) DecryptFailed(operation string, channel string) {
+    m.lock.Lock()
+    defer m.lock.Unlock()
+    m.metrics.DecryptFailures[channel]++
+}
+
+// TrackRequest is the struct Pubnub's instance method that sends a request once, logs its start
+// and its end with the status code and the duration, and reports it to the MetricsCollector of
+// the instance's Config. The failed and the non 200 responses are logged at the LogLevelWarn level.
+//
+// It accepts the following parameters:
+// operation: the operation of the request, e.g. OperationPublish.
+// channel: the pubnub channel or the comma separated channels of the request.
+// request: sends the request and returns the response contents, the response code and the error.
+//
+// returns the response contents, the response code and the error of the request.
+func (pub *Pubnub) TrackRequest(operation string, channel string, request func() ([]byte, int, error)) ([]byte, int, error) {
+    pub.Log(LogLevelDebug, "request started", LogFields{Operation: operation, Channel: channel})
+    start := time.Now()
+    value, responseCode, err := request()
+    duration := time.Since(start)
+    pub.config.Metrics.RequestCompleted(operation, responseCode, duration, err)
+    fields := LogFields{Operation: operation, Channel: channel, StatusCode: responseCode, Duration: duration, Err: err}
+    if ((err != nil) || (responseCode != 200)) {
+        pub.Log(LogLevelWarn, "request failed", fields)
+    } else {
+        pub.Log(LogLevelDebug, "request finished", fields)
+    }
+    return value, responseCode, err
+}
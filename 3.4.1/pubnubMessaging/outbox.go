@@ -0,0 +1,323 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// outbox.go contains the disk backed outbox of the publish requests that failed on the network.
+package pubnubMessaging
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "fmt"
+    "os"
+    "sync"
+    "time"
+)
+
+// The default delay between the replays of an Outbox while the network is unavailable.
+const _outboxRetryInterval = 5 * time.Second
+
+// The largest line of an Outbox file.
+const _outboxMaxLine = 1024 * 1024 //bytes
+
+// outboxRecord is a line of an Outbox file, a publish request saved to the outbox with its options
+// or, if Done, the end of the delivery of the saved request of the Id.
+type outboxRecord struct {
+    Id        string            `json:"id"`
+    Channel   string            `json:"channel,omitempty"`
+    Url       string            `json:"url,omitempty"`
+    Message   string            `json:"message,omitempty"`
+    Options   *PublishOptions   `json:"options,omitempty"`
+    Done      bool              `json:"done,omitempty"`
+}
+
+// outboxEntry is a publish request of an Outbox waiting to be delivered, with the channels
+// of the publish call that saved it if it was saved by the running process.
+type outboxEntry struct {
+    record            outboxRecord
+    callbackChannel   chan []byte
+    errorChannel      chan []byte
+}
+
+// Outbox saves to a file the publish requests that fail before being sent, on a connect error
+// (see IsConnectError), and replays them in the order they were saved, set with the Outbox of the Config. The publish
+// requests made while it has requests to deliver are saved after them.
+//
+// The file is append-only, each line is a json object: a saved request with its message id, or the
+// id of a delivered request. The file is truncated once all the saved requests are delivered.
+// The requests still in the file when it is opened by NewOutbox are replayed by the instance
+// created with the Outbox. An Outbox is used by a single instance, PubnubInitWithConfig doesn't
+// use it for another instance.
+//
+// A saved request is delivered when its publish succeeds or fails with another error than
+// a connect error, the outcome is sent on the callback or the error channel of the
+// publish call that saved it, or of the Outbox if it was saved by another process.
+// The replays are tried every RetryInterval and after each successful publish of the instance.
+//
+// The response of a saved request is held back until the requests saved before it are delivered,
+// that is while the origin can't be connected to, and is never sent if the Outbox is closed first.
+// The replays wait for each response to be read, a channel that isn't read holds back the
+// following requests until the Outbox is closed.
+type Outbox struct {
+    RetryInterval     time.Duration
+    file              *os.File
+    callbackChannel   chan []byte
+    errorChannel      chan []byte
+    lock              sync.Mutex
+    entries           []outboxEntry
+    wake              chan struct{}
+    ctx               context.Context
+    cancel            context.CancelFunc
+    pub               *Pubnub
+    stopped           sync.WaitGroup
+}
+
+// NewOutbox opens or creates the Outbox file and loads the requests saved to it and not yet delivered.
+//
+// It accepts the following parameters:
+// path: the path of the file.
+// callbackChannel: Channel on which to send the response of the requests saved by another process,
+// discarded if nil.
+// errorChannel on which the error response of the requests saved by another process is sent.
+// If nil the errors are sent to the Listeners.
+//
+// returns the pointer to the Outbox,
+// error if the file can't be opened.
+func NewOutbox(path string, callbackChannel chan []byte, errorChannel chan []byte) (*Outbox, error) {
+    file, err := os.OpenFile(path, os.O_RDWR | os.O_CREATE | os.O_APPEND, 0600)
+    if err != nil {
+        return nil, err
+    }
+    ctx, cancel := context.WithCancel(context.Background())
+    outbox := &Outbox{
+        RetryInterval:     _outboxRetryInterval,
+        file:              file,
+        callbackChannel:   callbackChannel,
+        errorChannel:      errorChannel,
+        wake:              make(chan struct{}, 1),
+        ctx:               ctx,
+        cancel:            cancel,
+    }
+    if err := outbox.load(); err != nil {
+        file.Close()
+        cancel()
+        return nil, err
+    }
+    return outbox, nil
+}
+
+// load reads the saved requests of the file that are not delivered.
+// A line that can't be parsed, e.g. the last one after a crash while writing it, is skipped.
+func (outbox *Outbox) load() error {
+    if _, err := outbox.file.Seek(0, 0); err != nil {
+        return err
+    }
+    var records []outboxRecord
+    delivered := make(map[string]bool)
+    scanner := bufio.NewScanner(outbox.file)
+    scanner.Buffer(make([]byte, 64 * 1024), _outboxMaxLine)
+    for scanner.Scan() {
+        var record outboxRecord
+        if err := json.Unmarshal(scanner.Bytes(), &record); (err != nil) || (record.Id == "") {
+            continue
+        }
+        if(record.Done){
+            delivered[record.Id] = true
+        } else {
+            records = append(records, record)
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return err
+    }
+    if err := outbox.endPartialLine(); err != nil {
+        return err
+    }
+    for _, record := range records {
+        if(!delivered[record.Id]){
+            outbox.entries = append(outbox.entries, outboxEntry{record: record})
+        }
+    }
+    return nil
+}
+
+// endPartialLine ends the last line of the file if it is partial, so that the records
+// appended afterwards are on their own lines.
+func (outbox *Outbox) endPartialLine() error {
+    info, err := outbox.file.Stat()
+    if ((err != nil) || (info.Size() == 0)) {
+        return err
+    }
+    last := make([]byte, 1)
+    if _, err := outbox.file.ReadAt(last, info.Size() - 1); err != nil {
+        return err
+    }
+    if (last[0] != '\n') {
+        _, err = outbox.file.Write([]byte{'\n'})
+    }
+    return err
+}
+
+// write appends the record to the file and syncs it to the disk.
+func (outbox *Outbox) write(record outboxRecord) error {
+    line, err := json.Marshal(record)
+    if err != nil {
+        return err
+    }
+    if _, err := outbox.file.Write(append(line, '\n')); err != nil {
+        return err
+    }
+    return outbox.file.Sync()
+}
+
+// Len returns the number of saved requests not yet delivered.
+func (outbox *Outbox) Len() int {
+    outbox.lock.Lock()
+    defer outbox.lock.Unlock()
+    return len(outbox.entries)
+}
+
+// Save saves a publish request to the file, it is replayed after the requests saved before it.
+//
+// It accepts the following parameters:
+// channel: pubnub channel to publish to.
+// publishUrlString: The url to which the message is to be appended.
+// jsonBytes: the message to be sent.
+// messageId: the id of the message, the same for all the replays.
+// options: the options of the publish request, saved with it.
+// callbackChannel: Channel on which to send the response once delivered.
+// errorChannel on which the error response is sent once delivered.
+//
+// returns error if the request can't be written to the file.
+func (outbox *Outbox) Save(channel string, publishUrlString string, jsonBytes []byte, messageId string, options PublishOptions, callbackChannel chan []byte, errorChannel chan []byte) error {
+    outbox.lock.Lock()
+    defer outbox.lock.Unlock()
+    if(outbox.ctx.Err() != nil){
+        return fmt.Errorf("Outbox closed.")
+    }
+    record := outboxRecord{
+        Id:        messageId,
+        Channel:   channel,
+        Url:       publishUrlString,
+        Message:   string(jsonBytes),
+        Options:   &options,
+    }
+    if err := outbox.write(record); err != nil {
+        return err
+    }
+    outbox.entries = append(outbox.entries, outboxEntry{record: record, callbackChannel: callbackChannel, errorChannel: errorChannel})
+    return nil
+}
+
+// Replay wakes the replay of the saved requests without waiting for the RetryInterval.
+func (outbox *Outbox) Replay() {
+    select {
+        case outbox.wake <- struct{}{}:
+        default:
+    }
+}
+
+// Close stops the replays and closes the file, the requests not yet delivered stay in the file.
+func (outbox *Outbox) Close() error {
+    outbox.cancel()
+    outbox.stopped.Wait()
+    outbox.lock.Lock()
+    defer outbox.lock.Unlock()
+    return outbox.file.Close()
+}
+
+// start binds the Outbox to the instance and starts the replays of the saved requests with it.
+//
+// returns error if the Outbox is already used by another instance.
+func (outbox *Outbox) start(pub *Pubnub) error {
+    outbox.lock.Lock()
+    defer outbox.lock.Unlock()
+    if(outbox.pub != nil){
+        return fmt.Errorf("Outbox already used by another instance.")
+    }
+    outbox.pub = pub
+    outbox.stopped.Add(1)
+    go outbox.run(pub)
+    return nil
+}
+
+// run replays the saved requests now, then every RetryInterval and when woken, until closed.
+func (outbox *Outbox) run(pub *Pubnub) {
+    defer outbox.stopped.Done()
+    for {
+        outbox.replay(pub)
+        timer := time.NewTimer(outbox.RetryInterval)
+        select {
+            case <-timer.C:
+            case <-outbox.wake:
+                timer.Stop()
+            case <-outbox.ctx.Done():
+                timer.Stop()
+                return
+        }
+    }
+}
+
+// replay publishes the saved requests in order until one fails with a connect error
+// or the Outbox is closed.
+func (outbox *Outbox) replay(pub *Pubnub) {
+    for {
+        outbox.lock.Lock()
+        if((len(outbox.entries) == 0) || (outbox.ctx.Err() != nil)){
+            outbox.lock.Unlock()
+            return
+        }
+        entry := outbox.entries[0]
+        outbox.lock.Unlock()
+
+        record := entry.record
+        options := PublishOptions{}
+        if(record.Options != nil){
+            options = *record.Options
+        }
+        value, responseCode, err := pub.RequestPublish(outbox.ctx, record.Channel, record.Url, []byte(record.Message), options)
+        if((outbox.ctx.Err() != nil) || IsConnectError(err)){
+            return
+        }
+        if errDone := outbox.delivered(); errDone != nil {
+            pub.Log(LogLevelError, "outbox write failed", LogFields{Operation: OperationPublish, Channel: record.Channel, Err: errDone})
+        }
+
+        callbackChannel, errorChannel := entry.callbackChannel, entry.errorChannel
+        if((callbackChannel == nil) && (errorChannel == nil)){
+            callbackChannel, errorChannel = outbox.callbackChannel, outbox.errorChannel
+        }
+        if ((err != nil) && (errorChannel == nil)) {
+            pub.SendErrorToChannel(nil, OperationPublish, record.Channel, responseCode, err)
+        } else if (err != nil) {
+            if errorValue, errJson := CreateErrorResponse(OperationPublish, record.Channel, responseCode, err); errJson == nil {
+                outbox.send(errorChannel, errorValue)
+            }
+        } else if (callbackChannel != nil) {
+            outbox.send(callbackChannel, []byte(fmt.Sprintf("%s", value)))
+        }
+    }
+}
+
+// send sends the value on the channel, it gives up if the Outbox is closed first.
+//
+// returns false if the Outbox is closed before the value is read.
+func (outbox *Outbox) send(c chan []byte, value []byte) bool {
+    select {
+        case c <- value:
+            return true
+        case <-outbox.ctx.Done():
+            return false
+    }
+}
+
+// delivered removes the first saved request and writes its end to the file,
+// the file is truncated once all the saved requests are delivered.
+func (outbox *Outbox) delivered() error {
+    outbox.lock.Lock()
+    defer outbox.lock.Unlock()
+    record := outbox.entries[0].record
+    outbox.entries = outbox.entries[1:]
+    if(len(outbox.entries) == 0){
+        return outbox.file.Truncate(0)
+    }
+    return outbox.write(outboxRecord{Id: record.Id, Done: true})
+}
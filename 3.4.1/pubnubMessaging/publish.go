@@ -0,0 +1,266 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// publish.go contains the options of the publish requests.
+package pubnubMessaging
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+// The query parameters of the publish options.
+const (
+    _storeParameter = "store"
+    _ttlParameter = "ttl"
+    _metaParameter = "meta"
+    _noReplicationParameter = "norep"
+)
+
+// The response sent on the callback channel of a publish request without its response body.
+const _publishSentResponse = "[1,\"Sent\"]"
+
+// PublishMethod is the http method a message is published with.
+type PublishMethod int
+
+// The http methods of the publish requests.
+// PublishMethodAuto publishes by POST the messages larger than the PublishPostThreshold of the
+// Config, the others by GET.
+// PublishMethodGet appends the message to the url path.
+// PublishMethodPost sends the message as the json body of the request.
+const (
+    PublishMethodAuto PublishMethod = iota
+    PublishMethodGet
+    PublishMethodPost
+)
+
+// PublishStore decides if a published message is stored in the history of the channel.
+type PublishStore int
+
+// The storage of the published messages.
+// PublishStoreDefault sends no store parameter, the message is stored as configured for the keys.
+// PublishStoreEnabled stores the message in the history.
+// PublishStoreDisabled doesn't store the message in the history.
+const (
+    PublishStoreDefault PublishStore = iota
+    PublishStoreEnabled
+    PublishStoreDisabled
+)
+
+// PublishOptions are the per call options of PublishWithOptions and PublishSyncWithOptions,
+// the zero value publishes like Publish.
+//
+// Method is the http method the message is published with.
+// Store decides if the message is stored in the history.
+// TTL is the time in hours the stored message is kept in the history, 0 to keep it as
+// configured for the keys.
+// Meta is the metadata sent with the message as a json object, separate from the message,
+// not sent if nil.
+// NoReplication, if true, doesn't replicate the message to the other regions.
+// SkipResponseBody, if true, completes the request once the response headers are received,
+// the response sent on the callback channel is then [1,"Sent"] without the timetoken.
+type PublishOptions struct {
+    Method             PublishMethod
+    Store              PublishStore
+    TTL                int
+    Meta               map[string]interface{}
+    NoReplication      bool
+    SkipResponseBody   bool
+}
+
+// QueryParameters returns the query parameters of the options, sent with the publish request
+// and included in its signature.
+//
+// returns the query parameters, empty for the zero value,
+// error if the TTL is negative or the Meta can't be serialized.
+func (options PublishOptions) QueryParameters() (url.Values, error) {
+    query := url.Values{}
+    switch options.Store {
+        case PublishStoreEnabled:
+            query.Set(_storeParameter, "1")
+        case PublishStoreDisabled:
+            query.Set(_storeParameter, "0")
+    }
+    if(options.TTL < 0){
+        return nil, fmt.Errorf("Invalid TTL: %d.", options.TTL)
+    }
+    if(options.TTL > 0){
+        query.Set(_ttlParameter, strconv.Itoa(options.TTL))
+    }
+    if(options.Meta != nil){
+        meta, err := json.Marshal(options.Meta)
+        if err != nil {
+            return nil, fmt.Errorf("error in serializing meta: %s", err)
+        }
+        query.Set(_metaParameter, string(meta))
+    }
+    if(options.NoReplication){
+        query.Set(_noReplicationParameter, "true")
+    }
+    return query, nil
+}
+
+// SerializeMessage calls json marshal on the message, and if a cipher key is used
+// encrypts it with EncryptString and calls json marshal on the encrypted string.
+//
+// It accepts the following parameters:
+// message: message to be posted.
+// cipherKey: the cipher key, empty if the message isn't encrypted.
+//
+// returns the serialized message,
+// error if any.
+func SerializeMessage(message interface{}, cipherKey string) ([]byte, error) {
+    jsonSerialized, err := json.Marshal(message)
+    if err != nil {
+        return nil, fmt.Errorf("error in serializing: %s", err)
+    }
+    if cipherKey != "" {
+        //Encrypt and Serialize
+        jsonEncBytes, errEnc := json.Marshal(EncryptString(cipherKey, fmt.Sprintf("%s", jsonSerialized)))
+        if errEnc != nil {
+            return nil, fmt.Errorf("error in serializing: %s", errEnc)
+        }
+        return jsonEncBytes, nil
+    }
+    return jsonSerialized, nil
+}
+
+// EncodedMessageSize returns the size in bytes of the serialized message once escaped in the url path.
+//
+// It accepts the following parameters:
+// jsonBytes: the serialized, and encrypted if a cipher key is used, message.
+func EncodedMessageSize(jsonBytes []byte) int {
+    encoded := url.URL{Path: string(jsonBytes)}
+    return len(encoded.EscapedPath())
+}
+
+// MessageSize returns the encoded size in bytes of a message published by GET, the size checked
+// against the MaxMessageSize of the Config before it is published in the url: the size of the
+// message serialized with SerializeMessage and escaped in the url path. The size of a message
+// published by POST is the length of the serialized message, see PublishSize.
+//
+// It accepts the following parameters:
+// message: message to be posted.
+// cipherKey: the cipher key, empty if the message isn't encrypted.
+//
+// returns the size,
+// error if the message can't be serialized.
+func MessageSize(message interface{}, cipherKey string) (int, error) {
+    jsonBytes, err := SerializeMessage(message, cipherKey)
+    if err != nil {
+        return 0, err
+    }
+    return EncodedMessageSize(jsonBytes), nil
+}
+
+// PublishSize is the struct Pubnub's instance method that returns the size in bytes of the serialized
+// message as it is sent with the options: the length of the json body if it is published by POST,
+// see UsePost, else its EncodedMessageSize in the url path.
+//
+// It accepts the following parameters:
+// jsonBytes: the serialized, and encrypted if a cipher key is used, message.
+// options: the options of the publish request.
+func (pub *Pubnub) PublishSize(jsonBytes []byte, options PublishOptions) int {
+    if(pub.UsePost(jsonBytes, options)){
+        return len(jsonBytes)
+    }
+    return EncodedMessageSize(jsonBytes)
+}
+
+// ValidateMessageSize is the struct Pubnub's instance method that checks the PublishSize of the
+// serialized message against the MaxMessageSize of the instance's Config.
+//
+// It accepts the following parameters:
+// jsonBytes: the serialized, and encrypted if a cipher key is used, message.
+// options: the options of the publish request.
+//
+// returns a MessageTooLargeError if the message is larger than the limit, else nil.
+func (pub *Pubnub) ValidateMessageSize(jsonBytes []byte, options PublishOptions) error {
+    if(pub.config.MaxMessageSize < 0){
+        return nil
+    }
+    if size := pub.PublishSize(jsonBytes, options); size > pub.config.MaxMessageSize {
+        return &MessageTooLargeError{Size: size, MaxSize: pub.config.MaxMessageSize}
+    }
+    return nil
+}
+
+// UsePost is the struct Pubnub's instance method that returns true if the serialized message
+// should be published by POST with the options.
+//
+// It accepts the following parameters:
+// jsonBytes: the serialized, and encrypted if a cipher key is used, message.
+// options: the options of the publish request.
+func (pub *Pubnub) UsePost(jsonBytes []byte, options PublishOptions) bool {
+    switch options.Method {
+        case PublishMethodGet:
+            return false
+        case PublishMethodPost:
+            return true
+    }
+    return (pub.config.PublishPostThreshold >= 0) && (len(jsonBytes) > pub.config.PublishPostThreshold)
+}
+
+// CreatePublishRequest is the struct Pubnub's instance method that creates the structured publish
+// request of the serialized message. The message is appended to the publish url, or sent as the
+// json body of a POST request if UsePost returns true. The signature of the url is the same for both,
+// the url must already have all the query parameters, including the messageid, since the others are signed.
+//
+// It accepts the following parameters:
+// channel: pubnub channel to publish to.
+// publishUrlString: The url to which the message is to be appended.
+// jsonBytes: the message to be sent.
+// options: the options of the publish request.
+//
+// returns the pointer to the PubnubRequest,
+// error if the url can't be parsed.
+func (pub *Pubnub) CreatePublishRequest(channel string, publishUrlString string, jsonBytes []byte, options PublishOptions) (*PubnubRequest, error) {
+    request, err := ParsePubnubRequest(OperationPublish, channel, publishUrlString, false)
+    if err != nil {
+        return nil, err
+    }
+    if(pub.UsePost(jsonBytes, options)){
+        request.Method = "POST"
+        request.Path = strings.TrimSuffix(request.Path, "/")
+        request.Body = jsonBytes
+    } else {
+        request.Path += string(jsonBytes)
+    }
+    request.DiscardResponseBody = options.SkipResponseBody
+    return request, nil
+}
+
+// Fire is the struct Pubnub's instance method that publishes a message without storing it in the
+// history and without replicating it to the other regions, e.g. for the frequent messages
+// no one reads later. The request is sent with SendPublishRequestWithContext.
+//
+// It accepts the following parameters:
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted.
+// skipResponseBody: if true the request completes once the response headers are received and
+// [1,"Sent"] is sent on the callbackChannel.
+// callbackChannel: Channel on which to send the response back.
+// errorChannel on which the error response is sent, including the network errors.
+func (pub *Pubnub) Fire(channel string, message interface{}, skipResponseBody bool, callbackChannel chan []byte, errorChannel chan []byte) {
+    pub.FireWithContext(context.Background(), channel, message, skipResponseBody, callbackChannel, errorChannel)
+}
+
+// FireWithContext is the context aware variant of Fire.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted.
+// skipResponseBody: if true the request completes once the response headers are received.
+// callbackChannel: Channel on which to send the response back.
+// errorChannel on which the error response is sent, including the network errors.
+func (pub *Pubnub) FireWithContext(ctx context.Context, channel string, message interface{}, skipResponseBody bool, callbackChannel chan []byte, errorChannel chan []byte) {
+    options := PublishOptions{
+        Store:              PublishStoreDisabled,
+        NoReplication:      true,
+        SkipResponseBody:   skipResponseBody,
+    }
+    pub.PublishWithOptions(ctx, channel, message, options, callbackChannel, errorChannel)
+}
@@ -0,0 +1,300 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// publisher.go contains the background publish queue and its worker pool.
+package pubnubMessaging
+
+import (
+    "container/list"
+    "context"
+    "fmt"
+    "sync"
+)
+
+// The default number of messages a Publisher queues.
+const _publisherQueueSize = 1000 //messages
+
+// The default number of workers of a Publisher.
+const _publisherWorkers = 4 //workers
+
+// OverflowPolicy decides what Publisher.Publish does when the queue is full.
+type OverflowPolicy int
+
+// The policies of a full Publisher queue.
+// OverflowBlock waits until a message leaves the queue.
+// OverflowDropOldest drops the oldest queued message, a QueueFullError of its channel is sent on the
+// error channel of the Publisher.
+// OverflowError returns a QueueFullError without queueing the message.
+const (
+    OverflowBlock OverflowPolicy = iota
+    OverflowDropOldest
+    OverflowError
+)
+
+// PublisherConfig holds the settings of a Publisher.
+//
+// QueueSize is the number of messages waiting to be published the queue holds, 1000 if 0.
+// Workers is the number of messages published at once, 4 if 0.
+// OverflowPolicy decides what Publish does when the queue is full.
+// Options are the options of the publish requests.
+// BatchSize, if more than 1, is the largest number of queued messages of a channel published
+// together, as a single message holding the json array of the messages. A worker takes the messages
+// of the channel queued at that time, the batches are larger the more messages wait in the queue.
+// The batches should fit in the MaxMessageSize of the instance's Config.
+type PublisherConfig struct {
+    QueueSize        int
+    Workers          int
+    OverflowPolicy   OverflowPolicy
+    Options          PublishOptions
+    BatchSize        int
+}
+
+// withDefaults returns a copy of the PublisherConfig where the zero valued queue size
+// and workers are replaced by the package defaults, and a zero valued batch size by 1.
+func (config PublisherConfig) withDefaults() PublisherConfig {
+    if(config.BatchSize <= 0){
+        config.BatchSize = 1
+    }
+    if(config.QueueSize <= 0){
+        config.QueueSize = _publisherQueueSize
+    }
+    if(config.Workers <= 0){
+        config.Workers = _publisherWorkers
+    }
+    return config
+}
+
+// queuedMessage is a message waiting in the queue of a Publisher.
+type queuedMessage struct {
+    channel   string
+    message   interface{}
+}
+
+// Publisher publishes the messages in the background from a bounded queue with a pool of workers,
+// created with NewPublisher. The messages of a channel are published one at a time in the order
+// they were queued, the messages of different channels are published at once. Each message is
+// sent in its own publish request, or with the messages batched with it if the BatchSize of the
+// config is more than 1.
+//
+// The responses are sent on the callback channel and the errors on the error channel of the
+// Publisher, one for each publish request, the workers wait for them to be read.
+//
+// order holds all the queued messages from the oldest to the newest, queues holds the elements of
+// order of each channel. ready are the channels a worker can take a message of, in the order they
+// became ready; a channel that became in flight or empty since is skipped when it is taken.
+type Publisher struct {
+    pub               *Pubnub
+    config            PublisherConfig
+    callbackChannel   chan []byte
+    errorChannel      chan []byte
+    discard           chan []byte
+    lock              sync.Mutex
+    changed           *sync.Cond
+    order             *list.List
+    queues            map[string][]*list.Element
+    ready             []string
+    inFlight          map[string]bool
+    pending           int
+    closed            bool
+    workers           sync.WaitGroup
+}
+
+// NewPublisher is the struct Pubnub's instance method that creates a Publisher of the instance
+// and starts its workers.
+//
+// It accepts the following parameters:
+// config: the settings of the Publisher.
+// callbackChannel: Channel on which to send the response of each message, the responses are
+// discarded if nil.
+// errorChannel on which the error response is sent. If nil the errors are sent to the Listeners.
+//
+// returns the pointer to the Publisher.
+func (pub *Pubnub) NewPublisher(config PublisherConfig, callbackChannel chan []byte, errorChannel chan []byte) *Publisher {
+    publisher := &Publisher{
+        pub:               pub,
+        config:            config.withDefaults(),
+        callbackChannel:   callbackChannel,
+        errorChannel:      errorChannel,
+        order:             list.New(),
+        queues:            make(map[string][]*list.Element),
+        inFlight:          make(map[string]bool),
+    }
+    publisher.changed = sync.NewCond(&publisher.lock)
+    if(callbackChannel == nil){
+        publisher.discard = make(chan []byte)
+        publisher.callbackChannel = publisher.discard
+        go func(){
+            for range publisher.discard {
+            }
+        }()
+    }
+    for i := 0; i < publisher.config.Workers; i++ {
+        publisher.workers.Add(1)
+        go publisher.work()
+    }
+    return publisher
+}
+
+// Publish queues the message, it is published by the workers of the Publisher with the Options
+// of its config. If the queue is full the OverflowPolicy of the config is applied.
+//
+// It accepts the following parameters:
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted.
+//
+// returns a QueueFullError if the queue is full with the OverflowError policy,
+// error if the Publisher is closed.
+func (publisher *Publisher) Publish(channel string, message interface{}) error {
+    var dropped []queuedMessage
+    publisher.lock.Lock()
+    for (!publisher.closed) && (publisher.order.Len() >= publisher.config.QueueSize) {
+        if(publisher.config.OverflowPolicy == OverflowError){
+            publisher.lock.Unlock()
+            return &QueueFullError{}
+        }
+        if(publisher.config.OverflowPolicy == OverflowDropOldest){
+            dropped = append(dropped, publisher.remove(publisher.order.Front()))
+            publisher.pending--
+            continue
+        }
+        publisher.changed.Wait()
+    }
+    if(publisher.closed){
+        publisher.lock.Unlock()
+        return fmt.Errorf("Publisher closed.")
+    }
+    queue := publisher.queues[channel]
+    if((len(queue) == 0) && !publisher.inFlight[channel]){
+        publisher.ready = append(publisher.ready, channel)
+    }
+    publisher.queues[channel] = append(queue, publisher.order.PushBack(queuedMessage{channel: channel, message: message}))
+    publisher.pending++
+    publisher.changed.Broadcast()
+    publisher.lock.Unlock()
+
+    for _, droppedMessage := range dropped {
+        publisher.pub.SendErrorToChannel(publisher.errorChannel, OperationPublish, droppedMessage.channel, 0, &QueueFullError{})
+    }
+    return nil
+}
+
+// Len returns the number of queued messages, without the ones being published.
+func (publisher *Publisher) Len() int {
+    publisher.lock.Lock()
+    defer publisher.lock.Unlock()
+    return publisher.order.Len()
+}
+
+// Flush waits until all the messages queued so far are published, or dropped.
+func (publisher *Publisher) Flush() {
+    publisher.lock.Lock()
+    defer publisher.lock.Unlock()
+    for (publisher.pending > 0) {
+        publisher.changed.Wait()
+    }
+}
+
+// Close stops accepting messages, waits until the queued messages are published and stops the workers.
+// The Publish calls waiting for room in the queue return an error.
+func (publisher *Publisher) Close() {
+    publisher.lock.Lock()
+    if(publisher.closed){
+        publisher.lock.Unlock()
+        publisher.workers.Wait()
+        return
+    }
+    publisher.closed = true
+    publisher.changed.Broadcast()
+    publisher.lock.Unlock()
+
+    publisher.workers.Wait()
+    if(publisher.discard != nil){
+        close(publisher.discard)
+    }
+}
+
+// remove takes the queued message of the element out of the queue, the element must be the oldest
+// queued message of its channel. The lock must be held.
+//
+// returns the message.
+func (publisher *Publisher) remove(element *list.Element) queuedMessage {
+    queued := publisher.order.Remove(element).(queuedMessage)
+    queue := publisher.queues[queued.channel]
+    if(len(queue) > 1){
+        publisher.queues[queued.channel] = queue[1:]
+    } else {
+        delete(publisher.queues, queued.channel)
+    }
+    return queued
+}
+
+// next waits for the oldest queued messages, up to the BatchSize of the config, of the next ready
+// channel, the channels having no message being published, and marks the channel in flight.
+//
+// returns the messages in the order they were queued,
+// false if the Publisher is closed and the queue is empty.
+func (publisher *Publisher) next() ([]queuedMessage, bool) {
+    publisher.lock.Lock()
+    defer publisher.lock.Unlock()
+    for {
+        for (len(publisher.ready) > 0) {
+            channel := publisher.ready[0]
+            publisher.ready = publisher.ready[1:]
+            queue := publisher.queues[channel]
+            if((len(queue) == 0) || publisher.inFlight[channel]){
+                continue
+            }
+            size := len(queue)
+            if(size > publisher.config.BatchSize){
+                size = publisher.config.BatchSize
+            }
+            batch := make([]queuedMessage, 0, size)
+            for (len(batch) < size) {
+                batch = append(batch, publisher.remove(publisher.queues[channel][0]))
+            }
+            publisher.inFlight[channel] = true
+            publisher.changed.Broadcast()
+            return batch, true
+        }
+        if(publisher.closed && (publisher.order.Len() == 0)){
+            return nil, false
+        }
+        publisher.changed.Wait()
+    }
+}
+
+// done marks the channel of the published messages no longer in flight, and ready if
+// it has queued messages.
+func (publisher *Publisher) done(batch []queuedMessage) {
+    publisher.lock.Lock()
+    defer publisher.lock.Unlock()
+    channel := batch[0].channel
+    delete(publisher.inFlight, channel)
+    if(len(publisher.queues[channel]) > 0){
+        publisher.ready = append(publisher.ready, channel)
+    }
+    publisher.pending -= len(batch)
+    publisher.changed.Broadcast()
+}
+
+// work publishes the queued messages until the Publisher is closed and the queue is empty.
+// The messages of a batch are published as the array of the messages if the BatchSize of the
+// config is more than 1.
+func (publisher *Publisher) work() {
+    defer publisher.workers.Done()
+    for {
+        batch, ok := publisher.next()
+        if(!ok){
+            return
+        }
+        message := batch[0].message
+        if(publisher.config.BatchSize > 1){
+            messages := make([]interface{}, len(batch))
+            for i, queued := range batch {
+                messages[i] = queued.message
+            }
+            message = messages
+        }
+        publisher.pub.PublishWithOptions(context.Background(), batch[0].channel, message, publisher.config.Options,
+            publisher.callbackChannel, publisher.errorChannel)
+        publisher.done(batch)
+    }
+}
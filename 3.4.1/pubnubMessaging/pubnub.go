@@ -11,7 +11,6 @@ import (
     "strings"
     "time"
     "net"
-    "crypto/tls"
     "net/url"
     "reflect"
     "bytes"
@@ -24,6 +23,8 @@ import (
     "encoding/base64"
     "encoding/hex"
     "io"
+    "sync"
+    "context"
 )
 
 // This string is appended to all presence channels 
@@ -39,56 +40,77 @@ const _operationTimeout = "Operation Timeout"
 // The string is returned as a message when the http request is aborted.
 const _connectionAborted = "Connection aborted"
 
-// The string is encountered when the http request couldn't connect to the origin.
-const _noSuchHost = "no such host"
-
 // The string is returned as a message when network connection is not avaialbe.
 const _networkUnavailable = "Network unavailable"
 
-// The string is encountered when the http request faces connectivity issues.
-const _closedNetworkConnection = "closed network connection"
-
-// The string is encountered when the http request faces connectivity issues.
-const _connectionResetByPeer = "connection reset by peer"
-
 // The string is returned as a message when the http request encounters network connectivity issues.
 const _connectionResetByPeerU = "Connection reset by peer"
 
-// The string is encountered the http request times out.
-const _timeout = "timeout"
-
-// The string is returned as a message when the http request times out.
-const _timeoutU = "Timeout"
-
 // The string is retured when the client faces issues in initializing the transport.
 const _errorInInitializing = "Error in initializing connection: "
 
 // The string is used when the server returns a non 200 response on publish 
 const _publishFailed = "Publish Failed"
 
-// The time after which the Publish/HereNow/DetailedHitsory/Unsubscribe/
+// The string is used when a Publisher can't queue a message.
+const _publishQueueFull = "Publish queue full"
+
+// The default time after which the Publish/HereNow/DetailedHitsory/Unsubscribe/
 // UnsibscribePresence/Time  request will timeout.
 // In seconds.
 const _nonSubscribeTimeout = 5 //sec
 
-// On Subscribe/Presence timeout, the number of times the reconnect attempts are made.
+// On Subscribe/Presence timeout, the default number of times the reconnect attempts are made.
 const _maxRetries = 50 //times
 
-// The delay in the reconnect attempts on timeout.
+// The default delay in the reconnect attempts on timeout.
 // In seconds.
 const _retryInterval = 10 //sec
 
-// The HTTP transport Dial timeout.
+// The default number of retries of the Publish/HereNow/DetailedHistory/Time requests.
+const _requestMaxRetries = 3 //times
+
+// The default delay before the first retry of the Publish/HereNow/DetailedHistory/Time requests,
+// doubled for each retry up to _requestRetryMaxDelay.
+const _requestRetryMinDelay = 500 * time.Millisecond
+
+// The default upper bound of the delay between the retries of the Publish/HereNow/DetailedHistory/Time requests.
+const _requestRetryMaxDelay = 5 * time.Second
+
+// The default time between the saves of the subscribe timetoken to the CheckpointStore
+// while no message is received.
+// In seconds.
+const _checkpointInterval = 10 //sec
+
+// The default size in bytes of the serialized messages above which they are published by POST.
+const _publishPostThreshold = 2048 //bytes
+
+// The default limit of the encoded size in bytes of the published messages.
+const _maxMessageSize = 32 * 1024 //bytes
+
+// The default HTTP transport Dial timeout.
 // In seconds.
 const _connectTimeout = 10 //sec
 
-// Root url value of pubnub api without the http/https protocol.
+// The default number of idle keep-alive connections to the origin kept by the transport 
+// of the Publish/HereNow/DetailedHistory/Unsubscribe/UnsubscribePresence/Time requests.
+const _maxIdleConnsPerHost = 10 //connections
+
+// The default time after which an idle keep-alive connection is closed.
+// In seconds.
+const _idleConnTimeout = 90 //sec
+
+// The interval of the TCP keep-alive probes of the connections.
+const _tcpKeepAlive = 30 * time.Second
+
+// Default root url value of pubnub api without the http/https protocol.
 var _origin = "pubsub.pubnub.com"
 
-// The time after which the Subscribe/Presence request will timeout.
+// The default time after which the Subscribe/Presence request will timeout.
 // In seconds.
 var _subscribeTimeout int64 = 310 //sec
 
+// Default value of Config.ResumeOnReconnect.
 // If _resumeOnReconnect is TRUE, then upon reconnect, 
 // it should use the last successfully retrieved timetoken. 
 // This has the effect of continuing, or “catching up” to missed traffic.
@@ -98,36 +120,19 @@ var _subscribeTimeout int64 = 310 //sec
 // Any messages received since the previous timeout or network error are skipped.
 var _resumeOnReconnect = true 
 
-// Global variable to reuse a commmon connection instance for non subscribe requests 
-// Publish/HereNow/DetailedHitsory/Unsubscribe/UnsibscribePresence/Time.
-var _conn net.Conn
-
-// Global variable to reuse a commmon connection instance for Subscribe/Presence requests.
-var _subscribeConn net.Conn
-
-// Global variable to reuse a commmon transport instance for Subscribe/Presence requests.
-var _subscribeTransport http.RoundTripper
-
-// Global variable to reuse a commmon transport instance for non subscribe requests 
-// Publish/HereNow/DetailedHitsory/Unsubscribe/UnsibscribePresence/Time.
-var _transport http.RoundTripper
-
-// No of retries made since disconnection.
-var _retryCount = 0
-
-// Global variable to store the proxy server if set.
+// Global variable to store the default proxy server if set.
 var _proxyServer string
 
-// Global variable to store the proxy port if set.
+// Global variable to store the default proxy port if set.
 var _proxyPort int
 
-// Global variable to store the proxy username if set.
+// Global variable to store the default proxy username if set.
 var _proxyUser string
 
-// Global variable to store the proxy password if set.
+// Global variable to store the default proxy password if set.
 var _proxyPassword string
 
-// Global variable to check if the proxy server if used.    
+// Global variable to check if the default proxy server if used.    
 var _proxyServerEnabled = false
 
 // 16 byte IV  
@@ -147,21 +152,18 @@ type ResponseStruct struct {
 // CipherKey stores the user specific Cipher Key in the current instance.
 // SSL is true if enabled, else is false for the current instance.
 // Uuid is the unique identifier, it can be a custom value or is automatically generated.
-// SubscribedChannels keeps a list of subscribed Pubnub channels by the user in the a comma separated string.
-// TimeToken is the current value of the servertime. This will be used to appened in each request.
-// SentTimeToken: This is the timetoken sent to the server with the request
-// ResetTimeToken: In case of a new request or an error this variable is set to true so that the 
-// timeToken will be set to 0 in the next request.
-// PresenceChannels: All the presence responses will be routed to this channel. It stores the response channels for 
-// each pubnub channel as map using the pubnub channel name as the key. 
-// SubscribeChannels: All the subscribe responses will be routed to this channel. It stores the response channels for 
-// each pubnub channel as map using the pubnub channel name as the key.
-// PresenceErrorChannels: All the presence error responses will be routed to this channel. It stores the response channels for 
-// each pubnub channel as map using the pubnub channel name as the key.
-// SubscribeErrorChannels: All the subscribe error responses will be routed to this channel. It stores the response channels for 
-// each pubnub channel as map using the pubnub channel name as the key.
-// NewSubscribedChannels keeps a list of the new subscribed Pubnub channels by the user in the a comma 
-// separated string, before they are appended to the Pubnub SubscribedChannels.
+// subscription is the state of the Subscribe/Presence subscriptions: the subscribed channels, the 
+// timetokens, the retry count and the Listeners. It is safe for concurrent use.
+// config is the instance's own copy of the Config it was initialized with.
+// transport and subscribeTransport are reused by the instance for the non subscribe 
+// (Publish/HereNow/DetailedHistory/Unsubscribe/UnsubscribePresence/Time) and the Subscribe/Presence 
+// requests respectively.
+// subscribeConn is the instance's live connection for the Subscribe/Presence requests, closing it 
+// never affects another instance.
+// subscribeCancel cancels the in-flight Subscribe/Presence request of the instance.
+// abortContext is the parent context of the non subscribe requests, abortCancel cancels it on Abort.
+// statusChannel is the channel of the StatusEvents of the instance, set with SetStatusChannel.
+// connLock guards the transports, the connections, the cancel funcs and the statusChannel.
 type Pubnub struct {
     Origin                   string
     PublishKey               string
@@ -170,15 +172,16 @@ type Pubnub struct {
     CipherKey                string
     Ssl                      bool
     Uuid                     string
-    SubscribedChannels       string 
-    TimeToken                string
-    SentTimeToken            string
-    ResetTimeToken           bool  
-    PresenceChannels         map[string] chan []byte
-    SubscribeChannels        map[string] chan []byte
-    PresenceErrorChannels    map[string] chan []byte
-    SubscribeErrorChannels   map[string] chan []byte
-    NewSubscribedChannels    string
+    subscription             *subscriptionState
+    config                   Config
+    transport                http.RoundTripper
+    subscribeTransport       http.RoundTripper
+    subscribeConn            net.Conn
+    subscribeCancel          context.CancelFunc
+    abortContext             context.Context
+    abortCancel              context.CancelFunc
+    statusChannel            chan StatusEvent
+    connLock                 sync.Mutex
 }
 
 // VersionInfo returns the version of the this code along with the build date. 
@@ -187,8 +190,7 @@ func VersionInfo() string{
 }
 
 // PubnubInit initializes pubnub struct with the user provided values.
-// And then initiates the origin by appending the protocol based upon the sslOn argument.
-// Then it uses the customuuid or generates the uuid.
+// The instance uses the package defaults returned by DefaultConfig.
 // 
 // It accepts the following parameters:
 // publishKey is the user specific Publish Key. Mandatory.
@@ -200,23 +202,38 @@ func VersionInfo() string{
 //
 // returns the pointer to Pubnub instance.
 func PubnubInit(publishKey string, subscribeKey string, secretKey string, cipherKey string, sslOn bool, customUuid string) *Pubnub {
+    config := DefaultConfig()
+    config.Ssl = sslOn
+    return PubnubInitWithConfig(publishKey, subscribeKey, secretKey, cipherKey, customUuid, config)
+}
+
+// PubnubInitWithConfig initializes pubnub struct with the user provided values and a copy of the config.
+// And then initiates the origin by appending the protocol based upon the config.Ssl value.
+// Then it uses the customuuid or generates the uuid.
+// 
+// Zero valued origin, timeouts and retry limits in the config are replaced by the package defaults.
+//
+// It accepts the following parameters:
+// publishKey is the user specific Publish Key. Mandatory.
+// subscribeKey is the user specific Subscribe Key. Mandatory.
+// secretKey is the user specific Secret Key. Accepts empty string if not used.
+// cipherKey stores the user specific Cipher Key. Accepts empty string if not used. 
+// customUuid is the unique identifier, it can be a custom value or sent as empty for automatic generation. 
+// config: the settings of the instance, usually created with DefaultConfig.
+//
+// returns the pointer to Pubnub instance.
+func PubnubInitWithConfig(publishKey string, subscribeKey string, secretKey string, cipherKey string, customUuid string, config Config) *Pubnub {
+    config = config.withDefaults()
     newPubnub := &Pubnub{
-        Origin:                _origin,
+        Origin:                config.Origin,
         PublishKey:            publishKey,
         SubscribeKey:          subscribeKey,
         SecretKey:             secretKey,
         CipherKey:             cipherKey,
-        Ssl:                   sslOn,
+        Ssl:                   config.Ssl,
         Uuid:                  "",
-        SubscribedChannels:    "",
-        ResetTimeToken:        true,
-        TimeToken:             "0",
-        SentTimeToken:             "0",
-        NewSubscribedChannels: "",
-        PresenceChannels:       make(map[string] chan []byte),
-        SubscribeChannels:       make(map[string] chan []byte),
-        PresenceErrorChannels:       make(map[string] chan []byte),
-        SubscribeErrorChannels:       make(map[string] chan []byte),
+        subscription:          newSubscriptionState(),
+        config:                config,
     }
 
     if newPubnub.Ssl {
@@ -231,15 +248,27 @@ func PubnubInit(publishKey string, subscribeKey string, secretKey string, cipher
         if err == nil {
             newPubnub.Uuid = uuid
         } else {
-            fmt.Println(err)
+            newPubnub.Log(LogLevelError, "uuid generation failed", LogFields{Err: err})
         }
     } else {
         newPubnub.Uuid = customUuid
      }
+    if(config.Outbox != nil){
+        if err := config.Outbox.start(newPubnub); err != nil {
+            newPubnub.Log(LogLevelError, "outbox not used", LogFields{Operation: OperationPublish, Err: err})
+            newPubnub.config.Outbox = nil
+        }
+    }
     return newPubnub
 }
 
-// SetProxy sets the global variables for the parameters.
+// Config returns a copy of the Config used by the current instance, changing its maps or slices
+// doesn't change the Config of the instance.
+func (pub *Pubnub) Config() Config {
+    return pub.config.clone()
+}
+
+// SetProxy sets the default proxy used by the instances created afterwards.
 // It also sets the _proxyServerEnabled value to true.
 // 
 // It accepts the following parameters:
@@ -255,43 +284,53 @@ func SetProxy(proxyServer string, proxyPort int, proxyUser string, proxyPassword
     _proxyServerEnabled = true
 }
 
-// SetResumeOnReconnect sets the value of _resumeOnReconnect.
+// SetResumeOnReconnect sets the default value of _resumeOnReconnect.
+// Should be called before PubnubInit.
 func SetResumeOnReconnect(val bool){
     _resumeOnReconnect = val
 }
 
-// SetSubscribeTimeout sets the value of _subscribeTimeout.
+// SetSubscribeTimeout sets the default value of _subscribeTimeout.
+// Should be called before PubnubInit.
 func SetSubscribeTimeout(val int64){
     _subscribeTimeout = val
 }
 
-// SetOrigin sets the value of _origin. Should be called before PubnubInit
+// SetOrigin sets the default value of _origin. Should be called before PubnubInit
 func SetOrigin(val string){
     _origin = val
 }
 
-// Abort is the struct Pubnub's instance method that closes the open connections for both subscribe 
-// and non-subscribe requests.
+// Abort is the struct Pubnub's instance method that closes the instance's open connections for both subscribe 
+// and non-subscribe requests. The connections of the other instances are not affected.
 //
-// It also sends a leave request for all the subscribed channel and
-// sets the pub.SubscribedChannels as empty to break the loop in the func StartSubscribeLoop  
+// It also empties the subscribed channels to break the loop in the func StartSubscribeLoop and
+// sends a leave request for all of them.
 func (pub *Pubnub) Abort() {
-    if(pub.SubscribedChannels != ""){
-        value, _, err := pub.SendLeaveRequest(pub.SubscribedChannels)
+    if subscribedChannels := pub.subscription.clear(); subscribedChannels != "" {
+        value, _, err := pub.SendLeaveRequest(subscribedChannels)
         if err != nil {
-            pub.SendResponseToChannel(nil, pub.SubscribedChannels, 9, err.Error(), "")
+            pub.SendErrorToChannel(nil, OperationLeave, subscribedChannels, 0, err)
         }else{
-            pub.SendResponseToChannel(nil, pub.SubscribedChannels, 5, string(value), "")
+            pub.SendRawResponseToChannel(nil, subscribedChannels, string(value))
         }            
-        
-        pub.SubscribedChannels = ""
     }
     
-    if(_conn != nil) {
-        _conn.Close()
+    pub.connLock.Lock()
+    defer pub.connLock.Unlock()
+    if(pub.abortCancel != nil) {
+        pub.abortCancel()
+        pub.abortContext = nil
+        pub.abortCancel = nil
+    }
+    if(pub.subscribeCancel != nil) {
+        pub.subscribeCancel()
     }
-    if(_subscribeConn!= nil) {
-        _subscribeConn.Close()
+    if(pub.subscribeConn != nil) {
+        pub.subscribeConn.Close()
+    }
+    if transport, ok := pub.transport.(*http.Transport); ok {
+        transport.CloseIdleConnections()
     }
 }
 
@@ -302,45 +341,62 @@ func (pub *Pubnub) Abort() {
 // callbackChannel on which to send the response.
 // errorChannel on which to send the error response. 
 func (pub *Pubnub) GetTime(callbackChannel chan []byte, errorChannel chan []byte) {
-    pub.ExecuteTime(callbackChannel, errorChannel, 0)
+    pub.GetTimeWithContext(context.Background(), callbackChannel, errorChannel)
+}
+
+// GetTimeWithContext is the context aware variant of GetTime.
+// Cancelling the ctx or reaching its deadline aborts the in-flight request.
+//. 
+// It accepts the following parameters:
+// ctx: the context of the request.
+// callbackChannel on which to send the response.
+// errorChannel on which to send the error response. 
+func (pub *Pubnub) GetTimeWithContext(ctx context.Context, callbackChannel chan []byte, errorChannel chan []byte) {
+    pub.ExecuteTimeWithContext(ctx, callbackChannel, errorChannel)
 }
 
 // ExecuteTime  is the struct Pubnub's instance method that creates a time request and sends back the 
 // response to the channel.
-// Closes the channel when the response is sent.
-// In case we get an invalid json response the routine retries till the _maxRetries to get a valid
-// response. 
+// The request is retried by RequestTime on the RetryableErrors of the instance's Config. 
 //
 // callbackChannel on which to send the response.
 // errorChannel on which the error response is sent.
-// retryCount to track the retry logic. 
+// retryCount: unused, the retries are counted by RequestTime.
 func (pub *Pubnub) ExecuteTime(callbackChannel chan []byte, errorChannel chan []byte, retryCount int) {
-    count := retryCount
-     
-    timeUrl := ""
-    timeUrl += "/time"
-    timeUrl += "/0"
-
-    value, _, err := pub.HttpRequest(timeUrl, false)
+    pub.ExecuteTimeWithContext(context.Background(), callbackChannel, errorChannel)
+}
 
+// ExecuteTimeWithContext is the context aware variant of ExecuteTime.
+//
+// ctx: the context of the request, no retry is made once it is done.
+// callbackChannel on which to send the response.
+// errorChannel on which the error response is sent.
+func (pub *Pubnub) ExecuteTimeWithContext(ctx context.Context, callbackChannel chan []byte, errorChannel chan []byte) {
+    value, responseCode, err := pub.RequestTime(ctx)
     if err != nil {        
-        pub.SendResponseToChannel(errorChannel, "", 10, err.Error(), "")
+        pub.SendErrorToChannel(errorChannel, OperationTime, "", responseCode, err)
     } else {
-        _, _, _, errJson := ParseJson(value, pub.CipherKey)
-        if(errJson != nil && strings.Contains(errJson.Error(), _invalidJson)){
-            pub.SendResponseToChannel(errorChannel, "", 10, errJson.Error(), "") 
-            if (count<_maxRetries) {
-                count++
-                pub.ExecuteTime(callbackChannel, errorChannel, count)    
-            }            
-        } else {
-            callbackChannel <- []byte(fmt.Sprintf("%s", value))
-        }
+        callbackChannel <- []byte(fmt.Sprintf("%s", value))
     }
 }
 
+// CreateTimeUrl creates the url of the time request.
+func (pub *Pubnub) CreateTimeUrl() string {
+    timeUrl := ""
+    timeUrl += "/time"
+    timeUrl += "/0"
+    return timeUrl
+}
+
 // SendPublishRequest is the struct Pubnub's instance method that posts a publish request and 
 // sends back the response to the channel.
+// The request is retried by RequestPublish on the RetryableErrors of the instance's Config. 
+// If it still fails before being sent, on a connect error (see IsConnectError), and the Config has
+// an Outbox, the request is saved to the Outbox and the response is sent once the Outbox delivers it.
+// The other errors, e.g. a connection reset after the request was sent, are not saved as the origin
+// may have stored the message. While the Outbox has
+// requests to deliver the request is saved to it without being sent, so that the messages are
+// published in order.
 //
 // It accepts the following parameters:
 // channel: pubnub channel to publish to
@@ -349,44 +405,50 @@ func (pub *Pubnub) ExecuteTime(callbackChannel chan []byte, errorChannel chan []
 // callbackChannel: Channel on which to send the response.
 // errorChannel on which the error response is sent.
 func (pub *Pubnub) SendPublishRequest(channel string, publishUrlString string, jsonBytes []byte, callbackChannel chan []byte, errorChannel chan []byte) {
-    var publishUrl *url.URL
-    publishUrl, urlErr := url.Parse(publishUrlString)
-    if urlErr != nil {
-        errorChannel <- []byte(fmt.Sprintf("%s", urlErr))
-    } else {
-        publishUrl.Path += string(jsonBytes)
-        value, responseCode, err := pub.HttpRequest(publishUrl.String(), false)
+    pub.SendPublishRequestWithContext(context.Background(), channel, publishUrlString, jsonBytes, CreateMessageId(), PublishOptions{},
+        callbackChannel, errorChannel)
+}
 
-        if ((responseCode != 200) || (err != nil)) {
-            if ((value != nil) && (responseCode > 0)) {
-                var s []interface{}
-                errJson := json.Unmarshal(value, &s)
-                
-                if ((errJson==nil) && (len(s) >0)){
-                    if message, ok := s[1].(string); ok { 
-                        pub.SendResponseToChannel(errorChannel, channel, 9, message, strconv.Itoa(responseCode))
-                    } else {
-                        pub.SendResponseToChannel(errorChannel, channel, 9, string(value), strconv.Itoa(responseCode))
-                    }  
-                } else {                
-                    pub.SendResponseToChannel(errorChannel, channel, 9, string(value), strconv.Itoa(responseCode))
-                }    
-            } else if ((err != nil) && (responseCode > 0))  {
-                pub.SendResponseToChannel(errorChannel, channel, 9, err.Error(),  strconv.Itoa(responseCode))
-            } else if (err != nil) {
-                pub.SendResponseToChannel(errorChannel, channel, 9, err.Error(), "")    
-            } else {
-                pub.SendResponseToChannel(errorChannel, channel, 9, _publishFailed, strconv.Itoa(responseCode))
-            }    
-        } else {
-            _, _, _, errJson := ParseJson(value, pub.CipherKey)
-            if(errJson != nil && strings.Contains(errJson.Error(), _invalidJson)){
-                pub.SendResponseToChannel(errorChannel, channel, 9, errJson.Error(), "") 
-            } else {
-                callbackChannel <- []byte(fmt.Sprintf("%s", value))
-            }
-        }    
+// SendPublishRequestWithContext is the context aware variant of SendPublishRequest, the request
+// is sent with the options.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: pubnub channel to publish to
+// publishUrlString: The url to which the message is to be appended.
+// jsonBytes: the message to be sent.
+// messageId: the id of the message, the messageid query parameter of the publish url.
+// options: the options of the publish request.
+// callbackChannel: Channel on which to send the response.
+// errorChannel on which the error response is sent.
+func (pub *Pubnub) SendPublishRequestWithContext(ctx context.Context, channel string, publishUrlString string, jsonBytes []byte, messageId string, options PublishOptions, callbackChannel chan []byte, errorChannel chan []byte) {
+    outbox := pub.config.Outbox
+    if ((outbox != nil) && (outbox.Len() > 0)) {
+        errOutbox := outbox.Save(channel, publishUrlString, jsonBytes, messageId, options, callbackChannel, errorChannel)
+        if (errOutbox == nil) {
+            pub.Log(LogLevelInfo, "publish queued behind the outbox", LogFields{Operation: OperationPublish, Channel: channel})
+            outbox.Replay()
+            return
+        }
+        pub.Log(LogLevelError, "outbox write failed", LogFields{Operation: OperationPublish, Channel: channel, Err: errOutbox})
+    }
+    value, responseCode, err := pub.RequestPublish(ctx, channel, publishUrlString, jsonBytes, options)
+    if ((err != nil) && (outbox != nil) && IsConnectError(err)) {
+        errOutbox := outbox.Save(channel, publishUrlString, jsonBytes, messageId, options, callbackChannel, errorChannel)
+        if (errOutbox == nil) {
+            pub.Log(LogLevelWarn, "publish saved to the outbox", LogFields{Operation: OperationPublish, Channel: channel, StatusCode: responseCode, Err: err})
+            return
+        }
+        pub.Log(LogLevelError, "outbox write failed", LogFields{Operation: OperationPublish, Channel: channel, Err: errOutbox})
     }
+    if (err != nil) {
+        pub.SendErrorToChannel(errorChannel, OperationPublish, channel, responseCode, err)
+    } else {
+        if ((outbox != nil) && (outbox.Len() > 0)) {
+            outbox.Replay()
+        }
+        callbackChannel <- []byte(fmt.Sprintf("%s", value))
+    }    
 }
 
 // InvalidMessage takes the message in form of a interface and checks if the message is nil or empty.
@@ -454,8 +516,34 @@ func InvalidChannel(channel string, c chan []byte) bool{
 // callbackChannel: Channel on which to send the response back.
 // errorChannel on which the error response is sent.
 func (pub *Pubnub) Publish(channel string, message interface{}, callbackChannel chan []byte, errorChannel chan []byte) {
+    pub.PublishWithContext(context.Background(), channel, message, callbackChannel, errorChannel)
+}
+
+// PublishWithContext is the context aware variant of Publish.
+// Cancelling the ctx or reaching its deadline aborts the in-flight request.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted.
+// callbackChannel: Channel on which to send the response back.
+// errorChannel on which the error response is sent.
+func (pub *Pubnub) PublishWithContext(ctx context.Context, channel string, message interface{}, callbackChannel chan []byte, errorChannel chan []byte) {
+    pub.PublishWithOptions(ctx, channel, message, PublishOptions{}, callbackChannel, errorChannel)
+}
+
+// PublishWithOptions is the variant of PublishWithContext that publishes the message with the options.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted.
+// options: the options of the publish request, e.g. the http method, the storage or the metadata.
+// callbackChannel: Channel on which to send the response back.
+// errorChannel on which the error response is sent.
+func (pub *Pubnub) PublishWithOptions(ctx context.Context, channel string, message interface{}, options PublishOptions, callbackChannel chan []byte, errorChannel chan []byte) {
     if(pub.PublishKey == ""){
-        pub.SendResponseToChannel(errorChannel, channel, 9, "Publish key required.", "")
+        pub.SendErrorToChannel(errorChannel, OperationPublish, channel, 0, fmt.Errorf("Publish key required."))
         return
     } 
 
@@ -464,13 +552,73 @@ func (pub *Pubnub) Publish(channel string, message interface{}, callbackChannel
     }
 
     if(InvalidMessage(message)){
-        pub.SendResponseToChannel(errorChannel, channel, 9, "Invalid Message.", "")
+        pub.SendErrorToChannel(errorChannel, OperationPublish, channel, 0, fmt.Errorf("Invalid Message."))
         return 
     }
 
+    query, err := options.QueryParameters()
+    if err != nil {
+        pub.SendErrorToChannel(errorChannel, OperationPublish, channel, 0, err)
+        return
+    }
+
+    jsonBytes, err := pub.SerializePublishMessage(message)
+    if err == nil {
+        err = pub.ValidateMessageSize(jsonBytes, options)
+    }
+    if err != nil {
+        pub.SendErrorToChannel(errorChannel, OperationPublish, channel, 0, err)
+    } else {
+        messageId := CreateMessageId()
+        query.Set(_messageIdParameter, messageId)
+        pub.SendPublishRequestWithContext(ctx, channel, pub.CreatePublishUrlWithQuery(channel, message, query), jsonBytes, messageId, options, callbackChannel, errorChannel)
+    }
+}
+
+// CreatePublishUrl is the struct Pubnub's instance method that creates the publish url 
+// to which the serialized message is appended.
+// Calls the GetHmacSha256 to generate a signature if a secretKey is to be used.
+//
+// It accepts the following parameters:
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted, used for the signature.
+//
+// returns the publish url.
+func (pub *Pubnub) CreatePublishUrl(channel string, message interface{}) string {
+    return pub.CreatePublishUrlWithQuery(channel, message, nil)
+}
+
+// CreatePublishUrlWithQuery is the variant of CreatePublishUrl that adds the query parameters
+// to the publish url, e.g. the ones of PublishOptions.QueryParameters.
+// The encoded query parameters, sorted by key, are appended to the signed content after a "?",
+// except the client generated messageid which is sent but not signed.
+//
+// It accepts the following parameters:
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted, used for the signature.
+// query: the query parameters, none if empty.
+//
+// returns the publish url.
+func (pub *Pubnub) CreatePublishUrlWithQuery(channel string, message interface{}, query url.Values) string {
+    encodedQuery := ""
+    if(len(query) > 0){
+        encodedQuery = "?" + query.Encode()
+    }
     signature := ""
     if pub.SecretKey != "" {
-        signature = GetHmacSha256(pub.SecretKey, fmt.Sprintf("%s/%s/%s/%s/%s", pub.PublishKey, pub.SubscribeKey, pub.SecretKey, channel, message))
+        signedQuery := ""
+        if(len(query) > 0){
+            signedValues := url.Values{}
+            for key, values := range query {
+                if(key != _messageIdParameter){
+                    signedValues[key] = values
+                }
+            }
+            if(len(signedValues) > 0){
+                signedQuery = "?" + signedValues.Encode()
+            }
+        }
+        signature = GetHmacSha256(pub.SecretKey, fmt.Sprintf("%s/%s/%s/%s/%s%s", pub.PublishKey, pub.SubscribeKey, pub.SecretKey, channel, message, signedQuery))
     } else {
         signature = "0"
     }
@@ -485,260 +633,29 @@ func (pub *Pubnub) Publish(channel string, message interface{}, callbackChannel
     publishUrlBuffer.WriteString("/")
     publishUrlBuffer.WriteString(channel)
     publishUrlBuffer.WriteString("/0/")
-
-    jsonSerialized, err := json.Marshal(message)
-    if err != nil {
-        pub.SendResponseToChannel(errorChannel, channel, 9, fmt.Sprintf("error in serializing: %s", err), "")
-    } else {
-        if pub.CipherKey != "" {
-            //Encrypt and Serialize
-            jsonEncBytes, errEnc := json.Marshal(EncryptString(pub.CipherKey, fmt.Sprintf("%s", jsonSerialized)))
-            if errEnc != nil {
-                pub.SendResponseToChannel(errorChannel, channel, 9, fmt.Sprintf("error in serializing: %s", errEnc), "")
-            } else {
-                pub.SendPublishRequest(channel, publishUrlBuffer.String(), jsonEncBytes, callbackChannel, errorChannel)
-            }
-        } else {
-            pub.SendPublishRequest(channel, publishUrlBuffer.String(), jsonSerialized, callbackChannel, errorChannel)
-        }
-    }
-}
-
-// SendResponseToChannel is the struct Pubnub's instance method that sends a reponse on the channel 
-// provided as an argument or to the subscribe / presence channel is the argument is nil. 
-//
-// Constructs the response based on the action (1-9). In case the action is 5 sends the response 
-// as in the parameter response. 
-//
-// It accepts the following parameters:
-// c: Channel on which to send the response back. Can be nil. If nil, assumes that if the channel name 
-// is suffixed with "-pnpres" it is a presence channel else subscribe channel and sends the response to all the 
-// respective channels. Then it fetches the corresonding channel from the pub.PresenceChannels or pub.SubscribeChannels
-// in case of callback and pub.PresenceErrorChannels or pub.SubscribeErrorChannels in case of error 
-//
-// channels: Pubnub Channels to send a response to. Comma separated string for multiple channels.
-// action: (1-9) 
-// response: can be nil, is used only in the case action is '5'. 
-// response2: Additional error info.
-func (pub *Pubnub) SendResponseToChannel(c chan []byte, channels string, action int, response string, response2 string){
-    message := ""
-    intResponse := "0"
-    sendReponseAsIs := false
-    sendErrorResponse := false
-    errorWithoutChannel := false
-    switch action {
-        case 1:
-            message = "already subscribed"
-            intResponse = "0"          
-        case 2:
-            message = "connected"
-            intResponse = "1"
-        case 3:
-            message = "unsubscribed"
-            intResponse = "1"
-        case 4:
-            message = "not subscribed"
-            intResponse = "0"
-        case 5:
-            sendReponseAsIs = true
-        case 6:
-            message = "reconnected"
-            intResponse = "1"
-        case 7:
-            message = "disconnected due to internet connection issues, trying to reconnect. Retry count:" + response
-            response = ""
-            intResponse = "0"
-            sendErrorResponse = true
-        case 8:
-            message = "aborted due to max retry limit"
-            intResponse = "0"
-            sendErrorResponse = true
-        case 9:
-            sendErrorResponse = true  
-            sendReponseAsIs = true
-            intResponse = "0" 
-        case 10:
-            errorWithoutChannel = true
-        case 11:
-            message = "timed out."
-            response = ""
-            intResponse = "0"
-            sendErrorResponse = true
-    }
-    
-    var value string
-    channelArray := strings.Split(channels, ",")
-    
-    for i := 0; i < len(channelArray); i++ {
-        responseChannel := c
-        presence := "Subscription to channel "
-        channel := strings.TrimSpace(channelArray[i])
-        if(channel == ""){
-            continue
-        }
-        
-        if(response == ""){
-            response = message
-        }
-            
-        if (sendErrorResponse){
-            isPresence := false
-            if(responseChannel == nil){
-                responseChannel, isPresence = pub.GetChannelForPubnubChannel(channel, true)
-            }        
-            if(isPresence){
-                presence = "Presence notifications for channel "
-            }
-            if(sendReponseAsIs){
-                presence = ""
-            }
-            if ((response2 != "") && (response2 != "0")){
-                value = fmt.Sprintf("[%s, \"%s%s\", %s, \"%s\"]", intResponse, presence, response, response2, strings.Replace(channel, _presenceSuffix, "", -1))
-            } else {
-                value = fmt.Sprintf("[%s, \"%s%s\", \"%s\"]", intResponse, presence, response, strings.Replace(channel, _presenceSuffix, "", -1))
-            }
-
-            if responseChannel!= nil {
-                responseChannel <- []byte(value)    
-            }            
-        } else {
-            isPresence := false
-            if(responseChannel == nil){
-                responseChannel, isPresence = pub.GetChannelForPubnubChannel(channel, false)
-            }    
-            if(isPresence) {
-                channel = strings.Replace(channel, _presenceSuffix, "", -1)
-                presence = "Presence notifications for channel "
-            }
-            
-            if(sendReponseAsIs){
-                value = strings.Replace(response, _presenceSuffix, "", -1)
-            } else {
-                value = fmt.Sprintf("[%s, \"%s'%s' %s\", \"%s\"]", intResponse, presence, channel, message, channel)
-            }
-            if responseChannel!= nil {
-                responseChannel <- []byte(value)    
-            }            
-        }
-    }  
-    if(errorWithoutChannel){
-        responseChannel := c
-        value = fmt.Sprintf("[%s, \"%s\"]", intResponse, response)
-        if responseChannel!= nil {
-            responseChannel <- []byte(value)    
-        }            
-    }      
-}
-
-// GetChannelForPubnubChannel parses the pubnub channel and returns the the callback or the erro channel
-// 
-// Accepts the pubnub channel name channel as string, the string is parsed to check if it is a
-// Subscribe or a Presence channel.
-// 
-// and isErrorChannel as bool. If it is true PresenceErrorChannels or SubscribeErrorChannels
-// will be used to fetch the corresponding channel.
-//
-// Returns channel to send a response on 
-// and bool if true means it is a pubnub Presence channel. Else it is a pubnub Subscribe channel. 
-func (pub *Pubnub) GetChannelForPubnubChannel(channel string, isErrorChannel bool) (chan []byte, bool) {
-    isPresence := strings.Contains(channel, _presenceSuffix)
-    if(isPresence){
-        channel = strings.Replace(string(channel), _presenceSuffix, "", -1)
-        if(isErrorChannel){
-            c, found := pub.PresenceErrorChannels[channel]
-            if(found){
-                return c, true
-            }
-        } else {
-            c, found := pub.PresenceChannels[channel]
-            if(found){
-                return c, true
-            }
-        }
-    }else {
-        if(isErrorChannel){
-            c, found := pub.SubscribeErrorChannels[channel]
-            if(found){
-                return c, false
-            }
-        } else {    
-            c, found := pub.SubscribeChannels[channel]
-            if(found){
-                return c, false
-            }
-        }            
-    }
-    return nil, false    
+    publishUrlBuffer.WriteString(encodedQuery)
+    return publishUrlBuffer.String()
 }
 
-// GetSubscribedChannels is the struct Pubnub's instance method that iterates through the Pubnub 
-// SubscribedChannels and appends the new channels.  
-//
-// It splits the Pubnub channels in the parameter by a comma and compares them to the existing 
-// subscribed Pubnub channels. 
-// If a new Pubnub channels is found it is appended to the Pubnub SubscribedChannels. The return 
-// parameter channelsModified is set to true 
-// If an subscribed pubnub channel is already present in the Pubnub SubscribedChannels it is added to 
-// the alreadySubscribedChannels string and a response is sent back to the channel  
+// SerializePublishMessage is the struct Pubnub's instance method that serializes the message
+// with SerializeMessage and the cipher key of the instance.
 //
 // It accepts the following parameters:
-// channels: Pubnub Channels to send a response to. Comma separated string for multiple channels.
-// c: Channel on which to send the response back. Can be nil. If nil assumes that if the channel name 
-// is suffixed with "-pnpres" it is a presence channel else subscribe channel and send the response to 
-// the respective channel.
-// isPresenceSubscribe: can be nil, is used only in the case action is '5'.
-// errorChannel: channel to send the error response to.
+// message: message to be posted.
 //
-// Returns:
-// subChannels: the Pubnub subscribed channels as a comma separated string.  
-// newSubChannels: the new Pubnub subscribed channels as a comma separated string.
-// b: The return parameter channelsModified is set to true if new channels are added.
-func (pub *Pubnub) GetSubscribedChannels(channels string, callbackChannel chan []byte, isPresenceSubscribe bool, errorChannel chan []byte) (subChannels string, newSubChannels string, b bool) {
-    channelArray := strings.Split(channels, ",")
-    subscribedChannels := pub.SubscribedChannels
-    newSubscribedChannels := ""
-    channelsModified := false
-    alreadySubscribedChannels := ""
-        
-    for i := 0; i < len(channelArray); i++ {
-        channelToSub := strings.TrimSpace(channelArray[i])
-        if(isPresenceSubscribe){
-            channelToSub += _presenceSuffix
-        } 
-        
-        if pub.NotDuplicate(channelToSub) {
-            if len(subscribedChannels)>0 {
-                subscribedChannels += ","
-            }
-            subscribedChannels += channelToSub
-                 
-            if len(newSubscribedChannels)>0 {
-                newSubscribedChannels += ","
-            }     
-            newSubscribedChannels += channelToSub
-            channelsModified = true
-        }else{
-            if len(alreadySubscribedChannels)>0 {
-                alreadySubscribedChannels += ","
-            }            
-            alreadySubscribedChannels += channelToSub
-        }
-    }
-    
-    if len(alreadySubscribedChannels)>0 {
-        pub.SendResponseToChannel(errorChannel, alreadySubscribedChannels, 1, "", "")
-    }    
-
-    return subscribedChannels, newSubscribedChannels, channelsModified
+// returns the serialized message,
+// error if any.
+func (pub *Pubnub) SerializePublishMessage(message interface{}) ([]byte, error) {
+    return SerializeMessage(message, pub.CipherKey)
 }
 
 // CheckForTimeoutAndRetries parses the error in case of subscribe error response. Its an Pubnub instance method.
-// If any of the strings "Error in initializating connection", "timeout", "no such host" 
-// are found it assumes that a network connection is lost.
+// If the error is a TimeoutError or a NetworkUnavailableError it assumes that a network connection is lost.
 // Sends a response to the subscribe/presence channel.
 //
-// If max retries limit is reached it empties the Pubnub SubscribedChannels thus initiating 
-// the subscribe/presence subscription closure.
+// In case of a NetworkUnavailableError it waits for the delay of the RetryPolicy of the instance. 
+// If the RetryPolicy allows no more attempts it empties the subscribed channels thus initiating 
+// the subscribe/presence subscription closure. The waits end early once the ctx is done.
 //
 // It accepts the following parameters:
 // err: error object
@@ -748,32 +665,37 @@ func (pub *Pubnub) GetSubscribedChannels(channels string, callbackChannel chan [
 // b: Bool variable true incase the connection is lost.
 // bTimeOut: bool variable true in case Timeout condition is met.
 func (pub *Pubnub) CheckForTimeoutAndRetries(err error, errChannel chan []byte) (bool, bool){
+    return pub.CheckForTimeoutAndRetriesWithContext(context.Background(), err, errChannel)
+}
+
+// CheckForTimeoutAndRetriesWithContext is the context aware variant of CheckForTimeoutAndRetries.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscribe loop.
+// err: error object
+// errChannel: channel to send a response to.
+//
+// Returns:
+// b: Bool variable true incase the connection is lost.
+// bTimeOut: bool variable true in case Timeout condition is met.
+func (pub *Pubnub) CheckForTimeoutAndRetriesWithContext(ctx context.Context, err error, errChannel chan []byte) (bool, bool){
     bRet := false
     bTimeOut := false
-    errorInitConn :=strings.Contains(err.Error(), _errorInInitializing)
-    if (errorInitConn){
-        SleepForAWhile(true)
-        pub.SendResponseToChannel(nil, pub.SubscribedChannels, 9, err.Error(), "Retry count: " + strconv.Itoa(_retryCount))
-        bRet = true
-    }else if  (strings.Contains(err.Error(), _timeoutU)) {
-        SleepForAWhile(false)
-        pub.SendResponseToChannel(nil, pub.SubscribedChannels, 11, strconv.Itoa(_retryCount), "")        
-        bRet = true
-        bTimeOut = true
-    }else if (strings.Contains(err.Error(), _noSuchHost) || strings.Contains(err.Error(), _networkUnavailable)){
-        SleepForAWhile(true)
-        pub.SendResponseToChannel(nil, pub.SubscribedChannels, 7, strconv.Itoa(_retryCount), "")
-        bRet = true
-    }
-    
-    if(_retryCount >= _maxRetries){
-        pub.SendResponseToChannel(nil, pub.SubscribedChannels, 8, "", "")
-        pub.SubscribedChannels = ""
-        _retryCount = 0
-    }
-    
-    if(_retryCount > 0){    
-        return bRet, bTimeOut
+    switch err.(type) {
+        case *TimeoutError:
+            pub.SleepForAWhileWithContext(ctx)
+            pub.SendStatus(nil, StatusTimeout, pub.subscription.getChannels(), pub.subscription.getRetryCount())
+            bRet = true
+            bTimeOut = true
+        case *NetworkUnavailableError:
+            attempt, delay, retry := pub.NextReconnectDelay(err)
+            pub.SendStatus(nil, StatusDisconnected, pub.subscription.getChannels(), attempt)
+            if(retry){
+                SleepContext(ctx, delay)
+            } else {
+                pub.StopOnMaxRetries(attempt)
+            }
+            bRet = true
     }
     return bRet, bTimeOut
 }
@@ -787,118 +709,138 @@ func (pub *Pubnub) CheckForTimeoutAndRetries(err error, errChannel chan []byte)
 // else parses the response. stores the time token if it is a timeout from server.
   
 // Checks For Timeout And Retries: 
+// The timetoken of the CheckpointStore of the instance's Config is loaded when the loop starts, and
+// saved with SaveCheckpoint after each response with messages and every CheckpointInterval.
 // If sent timetoken is 0 and the data is empty the connected response is sent back to the channel.
 // If no error is received the response is sent to the presence or subscribe pubnub channels. 
 // if the channel name is suffixed with "-pnpres" it is a presence channel else subscribe channel 
 // and send the response the the respective channel.
 //
+// The loop isn't started if the loop of the instance is already running.
+//
 // It accepts the following parameters:
 // channels: channels to subscribe.
 // errorChannel: Channel to send the error response to.
 //
 // TODO: Refactor
 func (pub *Pubnub) StartSubscribeLoop(channels string, errorChannel chan []byte){
-    channelCount := len(pub.SubscribedChannels)
-    channelsModified := false
+    if loopCtx := pub.subscription.startLoop(context.Background()); (loopCtx != nil) {
+        pub.StartSubscribeLoopWithContext(loopCtx, channels, errorChannel)
+    }
+}
+
+// StartSubscribeLoopWithContext is the context aware variant of StartSubscribeLoop, the subscribe
+// requests are sent and the loop pauses with the ctx, the loop stops once it is done.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscribe loop returned by the subscription state of the instance,
+// cancelled once no channel remains subscribed.
+// channels: channels to subscribe.
+// errorChannel: Channel to send the error response to.
+func (pub *Pubnub) StartSubscribeLoopWithContext(ctx context.Context, channels string, errorChannel chan []byte){
+    pub.LoadCheckpoint()
     for {
-         if len(pub.SubscribedChannels) > 0 {
-            if(len(pub.SubscribedChannels) != channelCount){
-                channelsModified = true
-            }
-            sentTimeToken := pub.TimeToken
-            subscribeUrl, sentTimeToken := pub.CreateSubscribeUrl(sentTimeToken)
-            value, responseCode, err := pub.HttpRequest(subscribeUrl, true)
+        subscribedChannels, sentTimeToken, ok := pub.subscription.next(ctx)
+        if(!ok){
+            break
+        }
+        subscribeUrl := pub.CreateSubscribeUrlForChannels(subscribedChannels, sentTimeToken)
+        value, responseCode, err := pub.TrackRequest(OperationSubscribe, subscribedChannels, func() ([]byte, int, error) {
+            return pub.SendRequestUrl(ctx, OperationSubscribe, subscribedChannels, subscribeUrl, true)
+        })
+        if (ctx.Err() != nil) {
+            continue
+        }
+        
+        if ((responseCode != 200) || (err != nil)) {
             
-            if ((responseCode != 200) || (err != nil)) {
-                
-                if(err!=nil){
-                    bNonTimeout, bTimeOut := pub.CheckForTimeoutAndRetries(err, errorChannel)
-                    if (strings.Contains(err.Error(), _connectionAborted)){
-                        pub.CloseExistingConnection()	
-                        pub.SendResponseToChannel(nil, pub.SubscribedChannels, 9, err.Error(), strconv.Itoa(responseCode))
-                    } else if(bNonTimeout){
-                        pub.CloseExistingConnection()
-                        if(bTimeOut){
-                            _, returnTimeToken, _, errJson := ParseJson(value, pub.CipherKey)
-                            if(errJson == nil){
-                               pub.TimeToken = returnTimeToken
-                            }
+            if(err!=nil){
+                bNonTimeout, bTimeOut := pub.CheckForTimeoutAndRetriesWithContext(ctx, err, errorChannel)
+                if _, aborted := err.(*AbortedError); aborted {
+                    pub.CloseExistingConnection()	
+                    pub.SendErrorToChannel(nil, OperationSubscribe, subscribedChannels, responseCode, err)
+                } else if(bNonTimeout){
+                    pub.CloseExistingConnection()
+                    if(bTimeOut){
+                        _, returnTimeToken, _, errJson := ParseJson(value, pub.CipherKey)
+                        if(errJson == nil){
+                           pub.subscription.setTimeToken(returnTimeToken)
                         }
-                        if (!_resumeOnReconnect) {
-                            pub.ResetTimeToken = true
-                        }
-                    } else {
-                        pub.CloseExistingConnection()
-                        pub.SendResponseToChannel(nil, pub.SubscribedChannels, 9, err.Error(), strconv.Itoa(responseCode))
-                        SleepForAWhile(true)
                     }
+                    if (!pub.config.ResumeOnReconnect) {
+                        pub.subscription.setResetTimeToken()
+                    }
+                } else {
+                    pub.CloseExistingConnection()
+                    pub.SendErrorToChannel(nil, OperationSubscribe, subscribedChannels, responseCode, err)
+                    pub.WaitToReconnect(ctx, err)
                 }
+            } else {
+                errStatus := CreateStatusCodeError("Subscribe Failed", responseCode, value)
+                pub.SendErrorToChannel(nil, OperationSubscribe, subscribedChannels, responseCode, errStatus)
+                pub.WaitToReconnect(ctx, errStatus)
+            }
+            continue
+        } else if string(value) != "" {                
+            if string(value) == "[]" {
+                pub.SleepForAWhileWithContext(ctx)
                 continue
-            } else if string(value) != "" {                
-                if string(value) == "[]" {
-                    SleepForAWhile(false)
-                    continue
-                }      
-                        
-                data, returnTimeToken, channelName, errJson := ParseJson(value, pub.CipherKey)
-                pub.TimeToken = returnTimeToken
-                if (data == "[]") {
-                    if(!channelsModified){
-                        
-                        channelsModified = false
-                    }
-                    if(sentTimeToken == "0"){
-                        pub.SendResponseToChannel(nil, pub.SubscribedChannels, 2, "", "")
-                        pub.NewSubscribedChannels = ""
-                    }
-                    _retryCount = 0
-                    continue
-                }            
-                pub.ParseHttpResponse(value, data, channelName, returnTimeToken, errJson, errorChannel)
-            } 
-        } else {
-            break
-        }    
+            }      
+                    
+            data, returnTimeToken, channelName, errJson := pub.ParseJsonAndTrackDecryptErrors(value, OperationSubscribe, subscribedChannels, nil)
+            pub.subscription.setTimeToken(returnTimeToken)
+            if ((errJson == nil) && pub.subscription.connected()) {
+                pub.SendStatus(nil, StatusConnected, subscribedChannels, pub.subscription.getRetryCount())
+            }
+            if (data == "[]") {
+                pub.subscription.resetRetryCount()
+                pub.SaveCheckpoint(returnTimeToken, false)
+                continue
+            }            
+            pub.ParseHttpResponse(value, data, channelName, returnTimeToken, errJson, errorChannel)
+            if (errJson == nil) {
+                pub.SaveCheckpoint(returnTimeToken, true)
+            } else {
+                pub.SleepForAWhileWithContext(ctx)
+            }
+        } 
     }    
 }
 
-// CreateSubscribeUrl creates a subscribe url to send to the origin
-// If the resetTimeToken flag is true 
-// it sends 0 to init the subscription. 
-// Else sends the last timetoken.
+// CreateSubscribeUrl creates a subscribe url of the subscribed channels to send to the origin.
+// If the timetoken is reset it sends 0 to init the subscription, else sends the last timetoken.
+// The timetoken is stored as the sent one.
 //
-// Accepts the sentTimeToken as a string parameter.
-// retunrs the Url and the senttimetoken based on the logic above .
+// Accepts the sentTimeToken as a string parameter, unused, the sent timetoken is kept by the instance.
+// returns the Url and the sent timetoken.
 func (pub *Pubnub) CreateSubscribeUrl(sentTimeToken string) (string, string){
+    channels, timeToken := pub.subscription.nextRequest()
+    return pub.CreateSubscribeUrlForChannels(channels, timeToken), timeToken
+}
+
+// CreateSubscribeUrlForChannels creates a subscribe url to send to the origin.
+//
+// It accepts the following parameters:
+// channels: the subscribed channels as a comma separated string.
+// timeToken: the timetoken of the request, 0 to init the subscription.
+//
+// returns the Url.
+func (pub *Pubnub) CreateSubscribeUrlForChannels(channels string, timeToken string) string {
     var subscribeUrlBuffer bytes.Buffer
     subscribeUrlBuffer.WriteString("/subscribe")
     subscribeUrlBuffer.WriteString("/")
     subscribeUrlBuffer.WriteString(pub.SubscribeKey)
     subscribeUrlBuffer.WriteString("/")
-    subscribeUrlBuffer.WriteString(pub.SubscribedChannels)
+    subscribeUrlBuffer.WriteString(channels)
     subscribeUrlBuffer.WriteString("/0")
-                
-    if (pub.ResetTimeToken) {
-        subscribeUrlBuffer.WriteString("/0")
-        sentTimeToken = "0"   
-        pub.SentTimeToken = "0"     
-        pub.ResetTimeToken = false
-    }else{
-        subscribeUrlBuffer.WriteString("/")
-        if(strings.TrimSpace(pub.TimeToken) == ""){
-            pub.TimeToken = "0"
-            pub.SentTimeToken = "0"
-        } else {
-            pub.SentTimeToken = sentTimeToken
-        }    
-        subscribeUrlBuffer.WriteString(pub.TimeToken)
-    }
+    subscribeUrlBuffer.WriteString("/")
+    subscribeUrlBuffer.WriteString(timeToken)
                 
     if pub.Uuid != "" {
         subscribeUrlBuffer.WriteString("?uuid=")
         subscribeUrlBuffer.WriteString(pub.Uuid)
     }
-    return subscribeUrlBuffer.String(), sentTimeToken
+    return subscribeUrlBuffer.String()
 }
 
 // ParseHttpResponse parses the http response from the orgin for the subscribe resquest 
@@ -914,12 +856,11 @@ func (pub *Pubnub) CreateSubscribeUrl(sentTimeToken string) (string, string){
 // errorChannel: channel to send an error response to.
 func (pub *Pubnub) ParseHttpResponse(value []byte, data string, channelName string, returnTimeToken string, errJson error, errorChannel chan []byte){
     if errJson != nil {
-        pub.SendResponseToChannel(nil, channelName, 9, fmt.Sprintf("%s", errJson), "")
-        SleepForAWhile(false)
+        pub.SendErrorToChannel(nil, OperationSubscribe, channelName, 0, errJson)
     } else {
-        _retryCount = 0
+        pub.subscription.resetRetryCount()
         if (channelName == ""){                        
-            channelName = pub.SubscribedChannels
+            channelName = pub.subscription.getChannels()
         }
         pub.SplitMessagesAndSendJsonResponse(data, returnTimeToken, channelName, errorChannel)
     }                 
@@ -955,7 +896,7 @@ func (pub *Pubnub) SplitMessagesAndSendJsonResponse (data string, returnTimeToke
                 case string:
                    length := len(vv)
                    if(length > 0){
-                          pub.SendJsonResponse(vv, returnTimeToken, channels)
+                          pub.SendMessage(CreateMessage(vv, returnTimeToken, channelSlice[0]))
                    }
                 case []interface{}:
                       pub.CreateAndSendJsonResponse(vv, returnTimeToken, channels)
@@ -964,9 +905,8 @@ func (pub *Pubnub) SplitMessagesAndSendJsonResponse (data string, returnTimeToke
     }
 }
 
-// SplitPresenceMessages splits the multiple messages 
-// unmarshals the data into the custom structure, 
-// calls the SendJsonResponse funstion to creates the json again.
+// SplitPresenceMessages splits the multiple presence events, 
+// unmarshals the data and calls SendMessage for each event.
 //
 // Parameters:
 // data: data to unmarshal,
@@ -974,27 +914,19 @@ func (pub *Pubnub) SplitMessagesAndSendJsonResponse (data string, returnTimeToke
 // channel: pubnub channel,
 // errorChannel: error channel to send a error response back.
 func (pub *Pubnub) SplitPresenceMessages(data []byte, returnTimeToken string, channel string, errorChannel chan []byte){
-    var occupants []struct {
-        Action string `json:"action"`
-        Uuid string `json:"uuid"`
-        Timestamp float64 `json:"timestamp"`
-        Occupancy int `json:"occupancy"`
-    }
+    var occupants []map[string]interface{}
     errUnmarshalMessages := json.Unmarshal(data, &occupants)
     if(errUnmarshalMessages !=nil){    
-        pub.SendResponseToChannel(nil, channel, 9, _invalidJson, "")
+        pub.SendErrorToChannel(nil, OperationSubscribe, channel, 0, &InvalidJsonError{Err: errUnmarshalMessages})
     } else {
         for i := range occupants {
-            intf := make([]interface{}, 1)
-            intf[0] = occupants[i]
-            pub.SendJsonResponse(intf, returnTimeToken, channel)
+            pub.SendMessage(CreateMessage(occupants[i], returnTimeToken, channel))
         }        
     }    
 }
     
-// SplitSubscribeMessages splits the multiple messages 
-// unmarshals the data into the custom structure, 
-// calls the SendJsonResponse funstion to creates the json again.
+// SplitSubscribeMessages splits the multiple messages, 
+// unmarshals the data and calls SendMessage for each message.
 //
 // Parameters:
 // data: data to unmarshal,
@@ -1005,18 +937,16 @@ func (pub *Pubnub) SplitSubscribeMessages(data string, returnTimeToken string, c
     var occupants []interface {}
     errUnmarshalMessages := json.Unmarshal([]byte(data), &occupants)
     if(errUnmarshalMessages !=nil){    
-        pub.SendResponseToChannel(nil, channel, 9, _invalidJson, "")
+        pub.SendErrorToChannel(nil, OperationSubscribe, channel, 0, &InvalidJsonError{Err: errUnmarshalMessages})
     } else {
         for i := range occupants {
-            intf := make([]interface{}, 1)
-            intf[0] = occupants[i]
-            pub.SendJsonResponse(intf, returnTimeToken, channel)           
+            pub.SendMessage(CreateMessage(occupants[i], returnTimeToken, channel))
         }        
     }    
 }
 
-// CreateAndSendJsonResponse marshals the data for each split message and calls
-// the SendJsonResponse multiple times to send response back to the channel
+// CreateAndSendJsonResponse splits the messages of a multi channel response and calls
+// SendMessage for each message with the pubnub channel at the same index
 //  
 // Accepts:
 // rawData: the data to parse and split, 
@@ -1028,12 +958,6 @@ func (pub *Pubnub) CreateAndSendJsonResponse(rawData interface{}, returnTimeToke
     switch vv := dataInterface.(type){
         case []interface{}:
             for i, u := range vv {
-                intf := make([]interface{}, 1)
-                if (reflect.TypeOf(u).Kind() == reflect.String){
-                    intf[0] = u
-                } else {
-                    intf[0] = vv[i]
-                }
                 channel := ""
                 
                 if(i <= len(channelSlice)-1){
@@ -1042,48 +966,32 @@ func (pub *Pubnub) CreateAndSendJsonResponse(rawData interface{}, returnTimeToke
                     channel = channelSlice[0]
                 } 
                 
-                pub.SendJsonResponse(intf, returnTimeToken, channel)
+                pub.SendMessage(CreateMessage(u, returnTimeToken, channel))
             }
     } 
 }
 
-// SendJsonResponse creates a json response and sends back to the response channel
-// 
-// Accepts:
-// message: response to send back, 
-// returnTimeToken: the timetoken for the response, 
-// channelName: the pubnub channel for the response.
-func (pub *Pubnub) SendJsonResponse (message interface{}, returnTimeToken string, channelName string){
-                        
-    if(channelName != "") {
-        response := []interface{} {message, fmt.Sprintf("%s",pub.TimeToken), channelName}
-        jsonData, err := json.Marshal(response)
-        if (err != nil) {
-            pub.SendResponseToChannel(nil, channelName, 9, _invalidJson, err.Error())
-        }
-        pub.SendResponseToChannel(nil, channelName, 5, string(jsonData), "")    
-    }
-}
-
 // GetSubscribedChannelName is the struct Pubnub's instance method. 
 // In case of single subscribe request the channelname will be empty.
-// This methos iterates through the pubnub SubscribedChannels to find the name of the channel.
+// This method returns the first of the channels of the Subscribe subscriptions, empty if there is none.
 func (pub *Pubnub) GetSubscribedChannelName() (string){
-    channelArray := strings.Split(pub.SubscribedChannels, ",")
-    for i := 0; i < len(channelArray); i++ {
-        if (strings.Contains(channelArray[i], _presenceSuffix)) {
-            continue
-        }else{
-            return channelArray[i]
-        }            
+    channels := pub.SubscribedChannels()
+    if(len(channels) > 0){
+        return channels[0]
     }
     return ""
 }
 
-// CloseExistingConnection: Closes the open subscribe/presence connection.
+// CloseExistingConnection: Cancels the in-flight subscribe/presence request and 
+// closes the open subscribe/presence connection of the instance.
 func (pub *Pubnub) CloseExistingConnection(){
-    if(_subscribeConn != nil){
-        _subscribeConn.Close()
+    pub.connLock.Lock()
+    defer pub.connLock.Unlock()
+    if(pub.subscribeCancel != nil){
+        pub.subscribeCancel()
+    }
+    if(pub.subscribeConn != nil){
+        pub.subscribeConn.Close()
     }    
 }
 
@@ -1105,101 +1013,230 @@ func (pub *Pubnub) CloseExistingConnection(){
 // isPresenceSubscribe: tells the method that presence subscription is requested.
 // errorChannel: channel to send an error response to.
 func (pub *Pubnub) Subscribe(channels string, timetoken string, callbackChannel chan []byte, isPresenceSubscribe bool, errorChannel chan []byte) {
+    pub.SubscribeWithContext(context.Background(), channels, timetoken, callbackChannel, isPresenceSubscribe, errorChannel)
+}
+
+// SubscribeWithContext is the context aware variant of Subscribe.
+// When the ctx is done the channels are removed from the subscription without any further 
+// response on the callbackChannel or errorChannel, the in-flight subscribe request is aborted 
+// and the StartSubscribeLoop stops if no other channel remains subscribed.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscription.
+// channels: comma separated pubnub channel list.
+// timetoken: if timetoken is present the subscribe request is sent using this timetoken 
+// callbackChannel: Channel on which to send the response back.
+// isPresenceSubscribe: tells the method that presence subscription is requested.
+// errorChannel: channel to send an error response to.
+func (pub *Pubnub) SubscribeWithContext(ctx context.Context, channels string, timetoken string, callbackChannel chan []byte, isPresenceSubscribe bool, errorChannel chan []byte) {
     if(InvalidChannel(channels, callbackChannel)){
         return 
     }
-    
-    subscribedChannels, newSubscribedChannels, channelsModified := pub.GetSubscribedChannels(channels, callbackChannel, isPresenceSubscribe, errorChannel)
+    listener := &ChannelListener{
+        CallbackChannel:   callbackChannel,
+        ErrorChannel:      errorChannel,
+    }
+    pub.ExecuteSubscribe(ctx, channels, timetoken, listener, isPresenceSubscribe)
+}
 
-    var channelArr = strings.Split(channels, ",")
+// ExecuteSubscribe is the struct Pubnub's instance method that adds the listener to each of the 
+// channels, adds the channels that are not subscribed yet to the subscription and starts the 
+// StartSubscribeLoop, or closes the existing connection so that it resubscribes with the new channels.
+// The subscribe loop is started with the values of the ctx, it stops once no channel remains subscribed.
+// An AlreadySubscribed status is sent to the listener for the channels already subscribed.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscription.
+// channels: comma separated pubnub channel list.
+// timetoken: if timetoken is present the subscribe request is sent using this timetoken 
+// listener: the Listener of the channels, can be nil.
+// isPresenceSubscribe: tells the method that presence subscription is requested.
+func (pub *Pubnub) ExecuteSubscribe(ctx context.Context, channels string, timetoken string, listener Listener, isPresenceSubscribe bool) {
+    if (ctx.Done() != nil){
+        go pub.CancelSubscriptionOnDone(ctx, channels, isPresenceSubscribe, listener)
+    }
     
-    for i, u := range channelArr {
-        if isPresenceSubscribe {
-            pub.PresenceChannels[u] = callbackChannel
-            pub.PresenceErrorChannels[u] = errorChannel
-        } else {
-            pub.SubscribeChannels[u] = callbackChannel
-            pub.SubscribeErrorChannels[u] = errorChannel
+    if (listener != nil) {
+        var channelArr = strings.Split(channels, ",")
+        for _, u := range channelArr {
+            subscription := strings.TrimSpace(u)
+            if isPresenceSubscribe {
+                subscription += _presenceSuffix
+            }
+            pub.AddChannelListener(subscription, listener)
         }
-        i++
     }
-    pub.NewSubscribedChannels = newSubscribedChannels
-    if(pub.SubscribedChannels == ""){
-        if(strings.TrimSpace(timetoken) != ""){
-            pub.TimeToken = timetoken
-            pub.ResetTimeToken = false
-        } else {
-            pub.ResetTimeToken = true
-        }
-        pub.SubscribedChannels = subscribedChannels
-        go pub.StartSubscribeLoop(channels, errorChannel)
+    alreadySubscribedChannels, loopCtx, channelsModified := pub.subscription.add(ctx, channels, isPresenceSubscribe, timetoken)
+    if len(alreadySubscribedChannels)>0 {
+        pub.SendStatusToListener(listener, StatusAlreadySubscribed, alreadySubscribedChannels, pub.subscription.getRetryCount())
+    }
+    if(loopCtx != nil){
+        go pub.StartSubscribeLoopWithContext(loopCtx, channels, nil)
     }else if (channelsModified){  
         pub.CloseExistingConnection()
-        if(strings.TrimSpace(timetoken) != ""){
-            pub.TimeToken = timetoken
-            pub.ResetTimeToken = false
-        } else {
-            pub.ResetTimeToken = true
+    }
+}    
+
+// CancelSubscriptionOnDone is the struct Pubnub's instance method that waits for the ctx of 
+// the subscription to be done, then removes the listener from the channels. The channels without 
+// any other Listener are removed from the SubscribedChannels.
+// Closes the existing connection so that the StartSubscribeLoop either resubscribes without the 
+// channels or stops when none remain. A leave request is sent for the removed channels.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscription.
+// channels: comma separated pubnub channel list.
+// isPresenceSubscribe: true for a presence subscription.
+// listener: Listener the subscription was created with, can be nil.
+func (pub *Pubnub) CancelSubscriptionOnDone(ctx context.Context, channels string, isPresenceSubscribe bool, listener Listener) {
+    <-ctx.Done()
+    channelArray := strings.Split(channels, ",")
+    leaveChannels := ""
+    for i := 0; i < len(channelArray); i++ {
+        channel := strings.TrimSpace(channelArray[i])
+        channelToUnsub := channel
+        if(isPresenceSubscribe){
+            channelToUnsub += _presenceSuffix
+        }
+        if(listener != nil){
+            found, remaining := pub.RemoveChannelListener(channelToUnsub, listener)
+            if(!found || (remaining > 0)){
+                continue
+            }
+        }
+        if(pub.RemoveFromSubscription(nil, channel, isPresenceSubscribe)){
+            if len(leaveChannels)>0 {
+                leaveChannels += ","
+            }
+            leaveChannels += channelToUnsub
         }
-        pub.SubscribedChannels = subscribedChannels
     }
-}    
+    if(leaveChannels != ""){
+        pub.CloseExistingConnection()
+        pub.SendLeaveRequest(leaveChannels)
+    }
+}
 
-// SleepForAWhile pauses the subscribe/presence loop for the _retryInterval. 
+// SleepForAWhile pauses for the default RetryInterval.
+// The retry is unused, the reconnect attempts are counted by each instance.
 func SleepForAWhile(retry bool){
-    if(retry) {
-        _retryCount++
-    }
     time.Sleep(_retryInterval * time.Second)
 }
 
-// NotDuplicate is the struct Pubnub's instance method which checks for the channel name 
-// to check in the existing pubnub SubscribedChannels.
-// 
+// SleepForAWhileWithContext is the struct Pubnub's instance method that pauses the subscribe/presence
+// loop for the RetryInterval of the instance's Config, or until the ctx is done.
+//
 // It accepts the following parameters:
-// channel: the Pubnub channel name to check in the existing pubnub SubscribedChannels.
+// ctx: the context of the subscribe loop.
+func (pub *Pubnub) SleepForAWhileWithContext(ctx context.Context){
+    SleepContext(ctx, time.Duration(pub.config.RetryInterval) * time.Second)
+}
+
+// SleepContext pauses for the delay or until the ctx is done.
 //
-// returns:
-// true if the channel is found.
-// false if not found.
-func (pub *Pubnub) NotDuplicate(channel string) (b bool){
-    var channels = strings.Split(pub.SubscribedChannels, ",")
-    for i, u := range channels {
-        if channel == u {
+// It accepts the following parameters:
+// ctx: the context of the pause.
+// delay: the duration of the pause.
+//
+// returns false if the ctx is done before the end of the delay.
+func SleepContext(ctx context.Context, delay time.Duration) bool {
+    timer := time.NewTimer(delay)
+    defer timer.Stop()
+    select {
+        case <-timer.C:
+            return true
+        case <-ctx.Done():
             return false
-        } 
-        i++
     }
-    return true 
+}
+
+// WaitToReconnect is the struct Pubnub's instance method that counts a reconnect attempt of the
+// subscribe/presence loop with NextReconnectDelay and pauses the loop for its delay, or stops the
+// loop if the RetryPolicy allows no more attempts.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscribe loop, the pause ends once it is done.
+// err: the error of the failed subscribe request.
+func (pub *Pubnub) WaitToReconnect(ctx context.Context, err error){
+    attempt, delay, ok := pub.NextReconnectDelay(err)
+    if(!ok){
+        pub.StopOnMaxRetries(attempt)
+        return
+    }
+    SleepContext(ctx, delay)
+}
+
+// NextReconnectDelay is the struct Pubnub's instance method that counts a reconnect attempt 
+// and asks the RetryPolicy of the instance for the delay before it. It is the single place where
+// the reconnect attempts are counted: the allowed ones are reported to the MetricsCollector of the
+// instance's Config and logged.
+//
+// It accepts the following parameters:
+// err: the error of the failed subscribe request.
+//
+// returns the number of the attempt,
+// the delay,
+// false if the RetryPolicy allows no more attempts.
+func (pub *Pubnub) NextReconnectDelay(err error) (int, time.Duration, bool) {
+    attempt := pub.subscription.incrementRetryCount()
+    delay, ok := pub.config.RetryPolicy.Delay(attempt)
+    if(ok){
+        pub.config.Metrics.ReconnectAttempted(attempt)
+        pub.Log(LogLevelWarn, "reconnecting", LogFields{Operation: OperationSubscribe, Channel: pub.subscription.getChannels(), Duration: delay, RetryCount: attempt, Err: err})
+    }
+    return attempt, delay, ok
+}
+
+// StopOnMaxRetries is the struct Pubnub's instance method that sends the MaxRetriesReached status,
+// empties the subscribed channels to stop the StartSubscribeLoop and resets the retry count.
+//
+// It accepts the following parameters:
+// attempt: the number of the refused reconnect attempt.
+func (pub *Pubnub) StopOnMaxRetries(attempt int) {
+    channels := pub.subscription.clear()
+    pub.Log(LogLevelError, "max retries reached", LogFields{Operation: OperationSubscribe, Channel: channels, RetryCount: attempt})
+    pub.SendStatus(nil, StatusMaxRetriesReached, channels, attempt)
+    pub.subscription.resetRetryCount()
 }
 
 // RemoveFromSubscribeList is the struct Pubnub's instance method which checks for the 
-// channel name to check in the existing pubnub SubscribedChannels and removes it if found 
+// channel name in the existing subscribed channels and removes it and its Listeners if found.
+// A channel suffixed with "-pnpres" is removed from the Presence subscriptions.
 // 
 // It accepts the following parameters:
 // c: Channel on which to send the response back.
-// channel: the pubnub channel name to check in the existing pubnub SubscribedChannels.
+// channel: the pubnub channel name to check in the existing subscribed channels.
 //
 // returns:
 // true if the channel is found and removed.
 // false if not found.
 func (pub *Pubnub) RemoveFromSubscribeList(c chan []byte, channel string) (b bool){
-    var channels = strings.Split(pub.SubscribedChannels, ",")
-    newChannels := ""
-    found := false
-    for _, u := range channels {
-        if channel == u {
-            found = true
-            pub.SendResponseToChannel(c, u, 3, "", "")
-        } else {
-            if len(newChannels)>0 {
-                newChannels += ","
-            }          
-            newChannels += u            
-        }
+    channel = strings.TrimSpace(channel)
+    if(strings.HasSuffix(channel, _presenceSuffix)){
+        return pub.RemoveFromSubscription(c, strings.TrimSuffix(channel, _presenceSuffix), true)
     }
+    return pub.RemoveFromSubscription(c, channel, false)
+}
+
+// RemoveFromSubscription is the struct Pubnub's instance method which checks for the 
+// channel name in the existing subscribed channels and removes it and its Listeners if found.
+// An Unsubscribed status is sent for the removed channel.
+// 
+// It accepts the following parameters:
+// c: Channel on which to send the response back.
+// channel: the pubnub channel name to check in the existing subscribed channels.
+// isPresenceSubscribe: true to remove the channel from the Presence subscriptions.
+//
+// returns:
+// true if the channel is found and removed.
+// false if not found.
+func (pub *Pubnub) RemoveFromSubscription(c chan []byte, channel string, isPresenceSubscribe bool) (b bool){
+    found := pub.subscription.remove(channel, isPresenceSubscribe)
     if found {
-        pub.SubscribedChannels = newChannels
+        if(isPresenceSubscribe){
+            channel += _presenceSuffix
+        }
+        pub.SendStatus(c, StatusUnsubscribed, channel, pub.subscription.getRetryCount())
     }
     return found
 }
@@ -1216,6 +1253,18 @@ func (pub *Pubnub) RemoveFromSubscribeList(c chan []byte, channel string) (b boo
 // callbackChannel: Channel on which to send the response back.
 // errorChannel: channel to send an error response to.
 func (pub *Pubnub) Unsubscribe(channels string, callbackChannel chan []byte, errorChannel chan []byte) {
+    pub.UnsubscribeWithContext(context.Background(), channels, callbackChannel, errorChannel)
+}
+
+// UnsubscribeWithContext is the context aware variant of Unsubscribe.
+// The ctx applies to the leave request.
+// 
+// It accepts the following parameters:
+// ctx: the context of the leave request.
+// channels: the pubnub channel(s) in a comma separated string.
+// callbackChannel: Channel on which to send the response back.
+// errorChannel: channel to send an error response to.
+func (pub *Pubnub) UnsubscribeWithContext(ctx context.Context, channels string, callbackChannel chan []byte, errorChannel chan []byte) {
     channelArray := strings.Split(channels, ",")
     unsubscribeChannels := ""
     channelRemoved := false
@@ -1226,9 +1275,9 @@ func (pub *Pubnub) Unsubscribe(channels string, callbackChannel chan []byte, err
         }
         channelToUnsub := strings.TrimSpace(channelArray[i]);
         unsubscribeChannels += channelToUnsub
-        removed := pub.RemoveFromSubscribeList(callbackChannel, channelToUnsub)
+        removed := pub.RemoveFromSubscription(callbackChannel, channelToUnsub, false)
         if !removed {
-            pub.SendResponseToChannel(callbackChannel, channelToUnsub, 4, "", "")
+            pub.SendStatus(callbackChannel, StatusNotSubscribed, channelToUnsub, pub.subscription.getRetryCount())
         } else {
             channelRemoved = true
         }
@@ -1237,12 +1286,12 @@ func (pub *Pubnub) Unsubscribe(channels string, callbackChannel chan []byte, err
     if(channelRemoved) {
         pub.CloseExistingConnection()
         
-        if (strings.TrimSpace(pub.SubscribedChannels) == "") {
-            value, _, err := pub.SendLeaveRequest(channels)        
+        if (pub.subscription.getChannels() == "") {
+            value, _, err := pub.SendLeaveRequestWithContext(ctx, channels)        
             if err != nil {
-                pub.SendResponseToChannel(errorChannel, channels, 9, err.Error(), "")
+                pub.SendErrorToChannel(errorChannel, OperationLeave, channels, 0, err)
             }else{
-                pub.SendResponseToChannel(callbackChannel, channels, 5, string(value), "")
+                pub.SendRawResponseToChannel(callbackChannel, channels, string(value))
             }
         }
     }
@@ -1260,6 +1309,18 @@ func (pub *Pubnub) Unsubscribe(channels string, callbackChannel chan []byte, err
 // callbackChannel: Channel on which to send the response back.
 // errorChannel: channel to send an error response to.
 func (pub *Pubnub) PresenceUnsubscribe(channels string, callbackChannel chan []byte, errorChannel chan []byte) {
+    pub.PresenceUnsubscribeWithContext(context.Background(), channels, callbackChannel, errorChannel)
+}
+
+// PresenceUnsubscribeWithContext is the context aware variant of PresenceUnsubscribe.
+// The ctx applies to the leave request.
+// 
+// It accepts the following parameters:
+// ctx: the context of the leave request.
+// channels: the pubnub channel(s) in a comma separated string.
+// callbackChannel: Channel on which to send the response back.
+// errorChannel: channel to send an error response to.
+func (pub *Pubnub) PresenceUnsubscribeWithContext(ctx context.Context, channels string, callbackChannel chan []byte, errorChannel chan []byte) {
     channelArray := strings.Split(channels, ",")
     presenceChannels := ""
     channelRemoved := false
@@ -1268,11 +1329,12 @@ func (pub *Pubnub) PresenceUnsubscribe(channels string, callbackChannel chan []b
         if i>0 {
             presenceChannels += ","
         }
-        channelToUnsub := strings.TrimSpace(channelArray[i]) + _presenceSuffix
+        channel := strings.TrimSpace(channelArray[i])
+        channelToUnsub := channel + _presenceSuffix
         presenceChannels += channelToUnsub
-        removed := pub.RemoveFromSubscribeList(callbackChannel, channelToUnsub) 
+        removed := pub.RemoveFromSubscription(callbackChannel, channel, true)
         if !removed {
-            pub.SendResponseToChannel(errorChannel, channelToUnsub, 4, "", "")
+            pub.SendStatus(errorChannel, StatusNotSubscribed, channelToUnsub, pub.subscription.getRetryCount())
         }else {
             channelRemoved = true
         }
@@ -1280,12 +1342,12 @@ func (pub *Pubnub) PresenceUnsubscribe(channels string, callbackChannel chan []b
     
     if(channelRemoved) {
         pub.CloseExistingConnection() 
-        if (strings.TrimSpace(pub.SubscribedChannels) == "") {
-            value, _, err := pub.SendLeaveRequest(presenceChannels)        
+        if (pub.subscription.getChannels() == "") {
+            value, _, err := pub.SendLeaveRequestWithContext(ctx, presenceChannels)        
             if err != nil {
-                pub.SendResponseToChannel(errorChannel, channels, 9, err.Error(), "")
+                pub.SendErrorToChannel(errorChannel, OperationLeave, channels, 0, err)
             }else{
-                pub.SendResponseToChannel(callbackChannel, channels, 5, string(value), "")
+                pub.SendRawResponseToChannel(callbackChannel, channels, string(value))
             }            
         }    
     }    
@@ -1301,6 +1363,20 @@ func (pub *Pubnub) PresenceUnsubscribe(channels string, callbackChannel chan []b
 // response error code,
 // error if any.
 func (pub *Pubnub) SendLeaveRequest(channels string) ([]byte, int, error){
+    return pub.SendLeaveRequestWithContext(context.Background(), channels)
+}
+
+// SendLeaveRequestWithContext is the context aware variant of SendLeaveRequest.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channels: Channels to leave
+//
+// returns:
+// the HttpRequest response contents as byte array.
+// response error code,
+// error if any.
+func (pub *Pubnub) SendLeaveRequestWithContext(ctx context.Context, channels string) ([]byte, int, error){
     var subscribeUrlBuffer bytes.Buffer
     subscribeUrlBuffer.WriteString("/v2/presence")
     subscribeUrlBuffer.WriteString("/sub-key/")
@@ -1310,7 +1386,9 @@ func (pub *Pubnub) SendLeaveRequest(channels string) ([]byte, int, error){
     subscribeUrlBuffer.WriteString("/leave?uuid=")
     subscribeUrlBuffer.WriteString(pub.Uuid)
     
-    return pub.HttpRequest(subscribeUrlBuffer.String(), false)
+    return pub.TrackRequest(OperationLeave, channels, func() ([]byte, int, error) {
+        return pub.SendRequestUrl(ctx, OperationLeave, channels, subscribeUrlBuffer.String(), false)
+    })
 }
 
 // History is the struct Pubnub's instance method which creates and post the History request 
@@ -1326,17 +1404,31 @@ func (pub *Pubnub) SendLeaveRequest(channels string) ([]byte, int, error){
 // reverse: to fetch the messages in ascending order
 // callbackChannel on which to send the response.
 // errorChannel on which the error response is sent.
-// retryCount to track the retry logic. 
 func (pub *Pubnub) History(channel string, limit int, start int64, end int64, reverse bool, callbackChannel chan []byte, errorChannel chan []byte) {
-    pub.ExecuteHistory(channel, limit, start, end, reverse, callbackChannel, errorChannel, 0)
+    pub.HistoryWithContext(context.Background(), channel, limit, start, end, reverse, callbackChannel, errorChannel)
+}
+
+// HistoryWithContext is the context aware variant of History.
+// Cancelling the ctx or reaching its deadline aborts the in-flight request.
+// 
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: a single value of the pubnub channel.
+// limit: number of history messages to return.
+// start: start time from where to begin the history messages.
+// end: end time till where to get the history messages.
+// reverse: to fetch the messages in ascending order
+// callbackChannel on which to send the response.
+// errorChannel on which the error response is sent.
+func (pub *Pubnub) HistoryWithContext(ctx context.Context, channel string, limit int, start int64, end int64, reverse bool, callbackChannel chan []byte, errorChannel chan []byte) {
+    pub.ExecuteHistoryWithContext(ctx, channel, limit, start, end, reverse, callbackChannel, errorChannel)
 }
 
 // ExecuteHistory is the struct Pubnub's instance method which creates and post the History request 
 // for a single pubnub channel.
 //
 // It parses the response to get the data and return it to the channel.
-// In case we get an invalid json response the routine retries till the _maxRetries to get a valid
-// response. 
+// The request is retried by RequestHistory on the RetryableErrors of the instance's Config. 
 // 
 // It accepts the following parameters:
 // channel: a single value of the pubnub channel.
@@ -1346,13 +1438,52 @@ func (pub *Pubnub) History(channel string, limit int, start int64, end int64, re
 // reverse: to fetch the messages in ascending order
 // callbackChannel on which to send the response.
 // errorChannel on which the error response is sent.
-// retryCount to track the retry logic.
+// retryCount: unused, the retries are counted by RequestHistory.
 func (pub *Pubnub) ExecuteHistory(channel string, limit int, start int64, end int64, reverse bool, callbackChannel chan []byte, errorChannel chan []byte, retryCount int) {
-    count := retryCount
+    pub.ExecuteHistoryWithContext(context.Background(), channel, limit, start, end, reverse, callbackChannel, errorChannel)
+}
+
+// ExecuteHistoryWithContext is the context aware variant of ExecuteHistory.
+// 
+// It accepts the following parameters:
+// ctx: the context of the request, no retry is made once it is done.
+// channel: a single value of the pubnub channel.
+// limit: number of history messages to return.
+// start: start time from where to begin the history messages.
+// end: end time till where to get the history messages.
+// reverse: to fetch the messages in ascending order
+// callbackChannel on which to send the response.
+// errorChannel on which the error response is sent.
+func (pub *Pubnub) ExecuteHistoryWithContext(ctx context.Context, channel string, limit int, start int64, end int64, reverse bool, callbackChannel chan []byte, errorChannel chan []byte) {
     if(InvalidChannel(channel, callbackChannel)){
         return 
     }
 
+    value, responseCode, err := pub.RequestHistory(ctx, channel, limit, start, end, reverse)
+    if err != nil {
+        pub.SendErrorToChannel(errorChannel, OperationHistory, channel, responseCode, err)
+    } else {
+        data, returnOne, returnTwo, _ := pub.ParseJsonAndTrackDecryptErrors(value, OperationHistory, channel, errorChannel)
+        var buffer bytes.Buffer
+        buffer.WriteString("[")
+        buffer.WriteString(data)
+        buffer.WriteString(",\"" + returnOne + "\",\"" + returnTwo + "\"]")
+           
+        callbackChannel <- []byte(fmt.Sprintf("%s", buffer.Bytes()))
+    }
+}
+
+// CreateHistoryUrl is the struct Pubnub's instance method that creates the History request url.
+// 
+// It accepts the following parameters:
+// channel: a single value of the pubnub channel.
+// limit: number of history messages to return, 100 if negative.
+// start: start time from where to begin the history messages.
+// end: end time till where to get the history messages.
+// reverse: to fetch the messages in ascending order
+//
+// returns the History request url.
+func (pub *Pubnub) CreateHistoryUrl(channel string, limit int, start int64, end int64, reverse bool) string {
     if(limit < 0){
         limit = 100
     }
@@ -1379,28 +1510,7 @@ func (pub *Pubnub) ExecuteHistory(channel string, limit int, start int64, end in
     historyUrlBuffer.WriteString("?count=")
     historyUrlBuffer.WriteString(fmt.Sprintf("%d", limit))
     historyUrlBuffer.WriteString(parameters.String())
-        
-    value, _, err := pub.HttpRequest(historyUrlBuffer.String(), false)
-
-    if err != nil {
-        pub.SendResponseToChannel(errorChannel, channel, 9, err.Error(), "")
-    } else {
-        data, returnOne, returnTwo, errJson := ParseJson(value, pub.CipherKey)
-        if(errJson != nil && strings.Contains(errJson.Error(), _invalidJson)){
-            pub.SendResponseToChannel(errorChannel, channel, 9, errJson.Error(), "") 
-            if (count<_maxRetries) {
-                count++
-                pub.ExecuteHistory(channel, limit, start, end, reverse, callbackChannel, errorChannel, count)    
-            }                  
-        } else {
-            var buffer bytes.Buffer
-            buffer.WriteString("[")
-            buffer.WriteString(data)
-            buffer.WriteString(",\"" + returnOne + "\",\"" + returnTwo + "\"]")
-               
-            callbackChannel <- []byte(fmt.Sprintf("%s", buffer.Bytes()))
-        }
-    }
+    return historyUrlBuffer.String()
 }
 
 // HereNow is the struct Pubnub's instance method which creates and posts the herenow 
@@ -1411,49 +1521,67 @@ func (pub *Pubnub) ExecuteHistory(channel string, limit int, start int64, end in
 // callbackChannel on which to send the response.
 // errorChannel on which the error response is sent.
 func (pub *Pubnub) HereNow(channel string, callbackChannel chan []byte, errorChannel chan []byte) {
-    pub.ExecuteHereNow(channel, callbackChannel, errorChannel, 0)
+    pub.HereNowWithContext(context.Background(), channel, callbackChannel, errorChannel)
+}
+
+// HereNowWithContext is the context aware variant of HereNow.
+// Cancelling the ctx or reaching its deadline aborts the in-flight request.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: a single value of the pubnub channel. 
+// callbackChannel on which to send the response.
+// errorChannel on which the error response is sent.
+func (pub *Pubnub) HereNowWithContext(ctx context.Context, channel string, callbackChannel chan []byte, errorChannel chan []byte) {
+    pub.ExecuteHereNowWithContext(ctx, channel, callbackChannel, errorChannel)
 }
 
-// ExecuteHereNow  is the struct Pubnub's instance method that creates a time request and sends back the 
+// ExecuteHereNow  is the struct Pubnub's instance method that creates a herenow request and sends back the 
 // response to the channel.
 // 
-// In case we get an invalid json response the routine retries till the _maxRetries to get a valid
-// response. 
+// The request is retried by RequestHereNow on the RetryableErrors of the instance's Config. 
 //
+// channel: a single value of the pubnub channel. 
 // callbackChannel on which to send the response.
 // errorChannel on which the error response is sent.
-// retryCount to track the retry logic.
+// retryCount: unused, the retries are counted by RequestHereNow.
 func (pub *Pubnub) ExecuteHereNow(channel string, callbackChannel chan []byte, errorChannel chan []byte, retryCount int) {
-    count := retryCount
-    
+    pub.ExecuteHereNowWithContext(context.Background(), channel, callbackChannel, errorChannel)
+}
+
+// ExecuteHereNowWithContext is the context aware variant of ExecuteHereNow.
+//
+// ctx: the context of the request, no retry is made once it is done.
+// channel: a single value of the pubnub channel. 
+// callbackChannel on which to send the response.
+// errorChannel on which the error response is sent.
+func (pub *Pubnub) ExecuteHereNowWithContext(ctx context.Context, channel string, callbackChannel chan []byte, errorChannel chan []byte) {
     if(InvalidChannel(channel, callbackChannel)){
         return
     }
 
+    value, responseCode, err := pub.RequestHereNow(ctx, channel)
+    if err != nil {
+        pub.SendErrorToChannel(errorChannel, OperationHereNow, channel, responseCode, err)
+    } else {        
+        callbackChannel <- []byte(fmt.Sprintf("%s", value))
+    }
+}
+
+// CreateHereNowUrl is the struct Pubnub's instance method that creates the herenow request url.
+//
+// It accepts the following parameters:
+// channel: a single value of the pubnub channel. 
+//
+// returns the herenow request url.
+func (pub *Pubnub) CreateHereNowUrl(channel string) string {
     var hereNowUrl bytes.Buffer
     hereNowUrl.WriteString("/v2/presence")
     hereNowUrl.WriteString("/sub-key/")
     hereNowUrl.WriteString(pub.SubscribeKey)
     hereNowUrl.WriteString("/channel/")
     hereNowUrl.WriteString(channel)
-
-    value, _, err := pub.HttpRequest(hereNowUrl.String(), false)
-
-    if err != nil {
-        pub.SendResponseToChannel(errorChannel, channel, 9, err.Error(), "")
-    } else {
-        //parsejson
-        _, _, _, errJson := ParseJson(value, pub.CipherKey)
-        if(errJson != nil && strings.Contains(errJson.Error(), _invalidJson)){
-            pub.SendResponseToChannel(errorChannel, channel, 9, errJson.Error(), "")
-            if (count<_maxRetries) {
-                count++
-                pub.ExecuteHereNow(channel, callbackChannel, errorChannel, count)    
-            }           
-        } else {        
-            callbackChannel <- []byte(fmt.Sprintf("%s", value))
-        }    
-    }
+    return hereNowUrl.String()
 }
 
 // GetData parses the interface data and decrypts the messages if the cipher key is provided.  
@@ -1464,6 +1592,12 @@ func (pub *Pubnub) ExecuteHereNow(channel string, callbackChannel chan []byte, e
 //
 // returns the decrypted and/or unescaped data json data as string.
 func GetData(rawData interface{}, cipherKey string) (string){
+    return getData(rawData, cipherKey, nil)
+}
+
+// getData is GetData calling onDecryptError, if not nil, with the index and the error of each
+// message that can't be decrypted.
+func getData(rawData interface{}, cipherKey string, onDecryptError func(int, error)) (string){
     dataInterface := rawData.(interface{})
     switch vv := dataInterface.(type){
         case string:
@@ -1474,7 +1608,7 @@ func GetData(rawData interface{}, cipherKey string) (string){
                 return fmt.Sprintf("%s", vv[0])
             }
         case []interface{}:
-            retval := ParseInterface(vv, cipherKey)
+            retval := parseInterface(vv, cipherKey, onDecryptError)
             if(retval != ""){
                 return retval
             }
@@ -1492,12 +1626,25 @@ func GetData(rawData interface{}, cipherKey string) (string){
 // 
 // returns the json marshalled string.  
 func ParseInterface(vv []interface{}, cipherKey string) (string){
+    return parseInterface(vv, cipherKey, nil)
+}
+
+// parseInterface is ParseInterface calling onDecryptError, if not nil, with the index and the
+// error of each message that can't be decrypted.
+func parseInterface(vv []interface{}, cipherKey string, onDecryptError func(int, error)) (string){
     for i, u := range vv {
         if (reflect.TypeOf(u).Kind() == reflect.String){
             var intf interface{} 
             
             if(cipherKey != ""){
-                intf = ParseCipherInterface(u, cipherKey)
+                var onError func(error)
+                if(onDecryptError != nil){
+                    index := i
+                    onError = func(err error) {
+                        onDecryptError(index, err)
+                    }
+                }
+                intf = parseCipherInterface(u, cipherKey, onError)
                 var returnedMessages interface{}
 
                 errUnmarshalMessages := json.Unmarshal([]byte(intf.(string)), &returnedMessages)
@@ -1539,9 +1686,18 @@ func ParseInterface(vv []interface{}, cipherKey string) (string){
 //
 // returns the decrypted data as interface.
 func ParseCipherInterface(data interface{}, cipherKey string) (interface{}){
+    return parseCipherInterface(data, cipherKey, nil)
+}
+
+// parseCipherInterface is ParseCipherInterface calling onDecryptError, if not nil, with the
+// error of the decryption.
+func parseCipherInterface(data interface{}, cipherKey string, onDecryptError func(error)) (interface{}){
     var intf interface{} 
     decrypted, errDecryption := DecryptString(cipherKey, data.(string))
     if(errDecryption != nil){
+        if(onDecryptError != nil){
+            onDecryptError(errDecryption)
+        }
         intf = data
     }else{
         intf = decrypted
@@ -1550,7 +1706,8 @@ func ParseCipherInterface(data interface{}, cipherKey string) (interface{}){
 }
 
 // ParseJson parses the json data. 
-// It extracts the actual data (value 0),
+// It extracts the actual data (value 0), or the object itself if the json data is an object 
+// (e.g. the herenow response),
 // Timetoken/from time in case of detailed history (value 1), 
 // pubnub channelname/timetoken/to time in case of detailed history (value 2).
 //
@@ -1564,6 +1721,58 @@ func ParseCipherInterface(data interface{}, cipherKey string) (interface{}){
 // pubnub channelname/timetoken/to time in case of detailed history (value 2).
 // error if any.
 func ParseJson (contents []byte, cipherKey string) (string, string, string, error){
+    return parseJson(contents, cipherKey, nil)
+}
+
+// ParseJsonAndTrackDecryptErrors is the struct Pubnub's instance method that parses the json data 
+// like ParseJson with the instance's cipher key. The messages that can't be decrypted are logged
+// at the LogLevelWarn level and reported to the MetricsCollector of the instance's Config, and 
+// a PubnubError wrapping the DecryptError is sent with SendErrorToChannel.
+//
+// The channel of the error of a subscribe response is the channel of the message, if the response
+// has the channels of its messages.
+//
+// It accepts the following parameters:
+// contents: the contents to parse.
+// operation: the operation of the request, e.g. OperationSubscribe.
+// channel: the pubnub channel or the comma separated channels of the request.
+// errorChannel: Channel on which to send the errors. Can be nil. If nil the errors are sent to the
+// Listeners of the channel.
+//
+// returns the values returned by ParseJson.
+func (pub *Pubnub) ParseJsonAndTrackDecryptErrors(contents []byte, operation string, channel string, errorChannel chan []byte) (string, string, string, error){
+    return pub.parseJsonAndTrackDecryptErrors(contents, operation, channel, func(errorChannelName string, errDecrypt error) {
+        pub.SendErrorToChannel(errorChannel, operation, errorChannelName, 0, errDecrypt)
+    })
+}
+
+// parseJsonAndTrackDecryptErrors is ParseJsonAndTrackDecryptErrors calling onDecryptError, if not nil,
+// with the channel and the error of each message that can't be decrypted instead of sending the errors.
+func (pub *Pubnub) parseJsonAndTrackDecryptErrors(contents []byte, operation string, channel string, onDecryptError func(string, error)) (string, string, string, error){
+    var indexes []int
+    var decryptErrors []error
+    data, returnOne, returnTwo, err := parseJson(contents, pub.CipherKey, func(index int, errDecrypt error) {
+        indexes = append(indexes, index)
+        decryptErrors = append(decryptErrors, errDecrypt)
+    })
+    messageChannels := strings.Split(returnTwo, ",")
+    for i, errDecrypt := range decryptErrors {
+        errorChannelName := channel
+        if((operation == OperationSubscribe) && (indexes[i] < len(messageChannels)) && (strings.TrimSpace(messageChannels[indexes[i]]) != "")){
+            errorChannelName = strings.TrimSpace(messageChannels[indexes[i]])
+        }
+        pub.Log(LogLevelWarn, "decrypt failed", LogFields{Operation: operation, Channel: errorChannelName, Err: errDecrypt})
+        pub.config.Metrics.DecryptFailed(operation, errorChannelName)
+        if(onDecryptError != nil){
+            onDecryptError(errorChannelName, errDecrypt)
+        }
+    }
+    return data, returnOne, returnTwo, err
+}
+
+// parseJson is ParseJson calling onDecryptError, if not nil, with the index and the error of
+// each message that can't be decrypted.
+func parseJson (contents []byte, cipherKey string, onDecryptError func(int, error)) (string, string, string, error){
     var s interface{}
     returnData := ""
     returnOne := ""
@@ -1583,7 +1792,7 @@ func ParseJson (contents []byte, cipherKey string) (string, string, string, erro
            case []interface{}:
                length := len(vv)
                if(length > 0){
-                   returnData = GetData(vv[0], cipherKey)
+                   returnData = getData(vv[0], cipherKey, onDecryptError)
                }
                if(length > 1){
                     returnOne = ParseInterfaceData(vv[1])
@@ -1591,9 +1800,11 @@ func ParseJson (contents []byte, cipherKey string) (string, string, string, erro
                if(length > 2){
                    returnTwo = ParseInterfaceData(vv[2])
                }
+           case map[string]interface{}:
+               returnData = string(contents)
         }
     } else {
-        err = fmt.Errorf(_invalidJson)
+        err = &InvalidJsonError{Err: err}
     }
     return returnData, returnOne, returnTwo, err
 }
@@ -1629,55 +1840,122 @@ func ParseInterfaceData(myInterface interface{}) string{
 // response error code if any.
 // error if any.
 func (pub *Pubnub) HttpRequest(requestUrl string, isSubscribe bool) ([]byte, int, error) {
-    contents, responseStatusCode, err := Connect(pub.Origin + requestUrl, isSubscribe)
+    return pub.HttpRequestWithContext(context.Background(), requestUrl, isSubscribe)
+}
+
+// HttpRequestWithContext is the context aware variant of HttpRequest.
+// The errors are mapped to the typed errors by ClassifyError, a cancelled ctx is reported as an 
+// AbortedError and a ctx deadline as a TimeoutError.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// requestUrl: the url to connect to.
+// isSubscribe: true if it is a subscribe request.
+//
+// returns:
+// the response contents as byte array.
+// response error code if any.
+// error if any.
+func (pub *Pubnub) HttpRequestWithContext(ctx context.Context, requestUrl string, isSubscribe bool) ([]byte, int, error) {
+    return pub.HttpRequestWithBody(ctx, "GET", requestUrl, nil, isSubscribe)
+}
+
+// HttpRequestWithBody is the variant of HttpRequestWithContext that sends the request with the http
+// method and, if not nil, the json body.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// method: the http method, e.g. "GET" or "POST".
+// requestUrl: the url to connect to.
+// body: the json body of the request, nil if none.
+// isSubscribe: true if it is a subscribe request.
+//
+// returns:
+// the response contents as byte array.
+// response error code if any.
+// error if any.
+func (pub *Pubnub) HttpRequestWithBody(ctx context.Context, method string, requestUrl string, body []byte, isSubscribe bool) ([]byte, int, error) {
+    return pub.httpRequest(ctx, method, requestUrl, body, isSubscribe, true)
+}
+
+// httpRequest is HttpRequestWithBody, the response body is not read if readBody is false.
+func (pub *Pubnub) httpRequest(ctx context.Context, method string, requestUrl string, body []byte, isSubscribe bool, readBody bool) ([]byte, int, error) {
+    contents, responseStatusCode, err := pub.connect(ctx, method, pub.Origin + requestUrl, body, isSubscribe, readBody)
     
     if err != nil {
-        if  (strings.Contains(err.Error(), _timeout)) {
-            return nil, responseStatusCode, fmt.Errorf(_operationTimeout)
-        } else if (strings.Contains(fmt.Sprintf("%s", err.Error()), _closedNetworkConnection)) {
-            return nil, responseStatusCode, fmt.Errorf(_connectionAborted)
-        } else if (strings.Contains(fmt.Sprintf("%s", err.Error()), _noSuchHost)) {
-            return nil, responseStatusCode, fmt.Errorf(_networkUnavailable)    
-        } else if (strings.Contains(fmt.Sprintf("%s", err.Error()), _connectionResetByPeer)) {
-            return nil, responseStatusCode, fmt.Errorf(_connectionResetByPeerU)    
-        } else {
-            return nil, responseStatusCode, err 
-        }
+        return nil, responseStatusCode, ClassifyError(err)
     } else {
-        if ((_retryCount > 0) && (isSubscribe)){
-            pub.SendResponseToChannel(nil, pub.SubscribedChannels, 6, "", "")
+        if retryCount := pub.subscription.getRetryCount(); (retryCount > 0) && (isSubscribe) {
+            channels := pub.subscription.getChannels()
+            pub.config.Metrics.Reconnected(retryCount)
+            pub.Log(LogLevelInfo, "reconnected", LogFields{Operation: OperationSubscribe, Channel: channels, RetryCount: retryCount})
+            pub.SendStatus(nil, StatusReconnected, channels, retryCount)
         }
     }
     
     return contents, responseStatusCode, err
 }
-// SetOrGetTransport creates the transport and sets it for reuse.
+// defaultPubnub is the instance of the package-level SetOrGetTransport, CreateHttpClient and Connect,
+// created on first use with the DefaultConfig.
+var defaultPubnub *Pubnub
+
+// defaultPubnubOnce creates the defaultPubnub.
+var defaultPubnubOnce sync.Once
+
+// getDefaultPubnub returns the defaultPubnub, it is created on the first call.
+func getDefaultPubnub() *Pubnub {
+    defaultPubnubOnce.Do(func(){
+        defaultPubnub = PubnubInitWithConfig("", "", "", "", "", DefaultConfig())
+    })
+    return defaultPubnub
+}
+
+// SetOrGetTransport creates the transport of the package's default instance, with the package 
+// settings at the time of its first use.
+//
+// Deprecated: use the SetOrGetTransport method of a Pubnub instance.
+func SetOrGetTransport(isSubscribe bool) (http.RoundTripper){
+    return getDefaultPubnub().SetOrGetTransport(isSubscribe)
+}
+
+// SetOrGetTransport is the struct Pubnub's instance method that creates the transport and sets it for reuse.
 // Creates a different transport for subscribe and non-subscribe requests. 
-// Also sets the proxy details if provided
-// It sets the timeouts based on the subscribe and non-subscribe requests.
+// Also sets the proxy details if provided in the instance's Config.
+// The TLS settings are created by the Config's CreateTlsConfig, the certificate of the origin is verified.
+//
+// No deadline is set on the connections, so the keep-alive connections of the non-subscribe requests are
+// reused until they are idle for the IdleConnTimeout of the Config, up to MaxIdleConnsPerHost of them.
+// Each request is bounded by a ResponseHeaderTimeout of the subscribe or the non-subscribe timeout, and 
+// by the overall timeout of the context created by CreateRequestContext.
 // 
 // It accepts the following parameters:
 // isSubscribe: true if it is a subscribe request.
 //
 // returns:
 // the transport.   
-func SetOrGetTransport(isSubscribe bool) (http.RoundTripper){
-    transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, 
-        Dial: func(netw, addr string) (net.Conn, error) {
-            c, err := net.DialTimeout(netw, addr, _connectTimeout * time.Second)
+func (pub *Pubnub) SetOrGetTransport(isSubscribe bool) (http.RoundTripper){
+    config := pub.config
+    dialer := &net.Dialer{
+        Timeout:     time.Duration(config.ConnectTimeout) * time.Second,
+        KeepAlive:   _tcpKeepAlive,
+    }
+    transport := &http.Transport{TLSClientConfig: config.CreateTlsConfig(), 
+        TLSHandshakeTimeout:      time.Duration(config.ConnectTimeout) * time.Second,
+        ResponseHeaderTimeout:    time.Duration(config.NonSubscribeTimeout) * time.Second,
+        MaxIdleConns:             config.MaxIdleConnsPerHost,
+        MaxIdleConnsPerHost:      config.MaxIdleConnsPerHost,
+        IdleConnTimeout:          time.Duration(config.IdleConnTimeout) * time.Second,
+        DialContext: func(ctx context.Context, netw, addr string) (net.Conn, error) {
+            c, err := dialer.DialContext(ctx, netw, addr)
             
             if(c != nil){
                 if(isSubscribe){
-                    deadline := time.Now().Add(time.Duration(_subscribeTimeout) * time.Second)
-                    c.SetDeadline(deadline)            
-                    _subscribeConn = c
-                } else {
-                    deadline := time.Now().Add(_nonSubscribeTimeout * time.Second)
-                    c.SetDeadline(deadline)
-                    _conn = c
+                    pub.connLock.Lock()
+                    pub.subscribeConn = c
+                    pub.connLock.Unlock()
                 }
             } else {
-                err = fmt.Errorf(_errorInInitializing ,err.Error())
+                err = &NetworkUnavailableError{Message: _errorInInitializing + err.Error(), Err: err}
             }
                 
             if err != nil {
@@ -1686,20 +1964,36 @@ func SetOrGetTransport(isSubscribe bool) (http.RoundTripper){
             
             return c, nil
     }}
+    if(isSubscribe){
+        transport.ResponseHeaderTimeout = time.Duration(config.SubscribeTimeout) * time.Second
+        transport.MaxIdleConns = 1
+        transport.MaxIdleConnsPerHost = 1
+    }
 
-    if(_proxyServerEnabled){
-        proxyUrl, err := url.Parse(fmt.Sprintf("http://%s:%s@%s:%d", _proxyUser, _proxyPassword, _proxyServer, _proxyPort))
+    if(config.ProxyEnabled()){
+        proxyUrl, err := url.Parse(fmt.Sprintf("http://%s:%s@%s:%d", config.ProxyUser, config.ProxyPassword, config.ProxyServer, config.ProxyPort))
         if(err == nil){ 
             transport.Proxy = http.ProxyURL(proxyUrl)
         } else {
-            fmt.Println("Error in connecting to proxy: ", err)
+            pub.Log(LogLevelError, "invalid proxy url", LogFields{Err: err})
         }
     }
     return transport
 }
 
-// CreateHttpClient creates the http.Client by creating or reusing the transport for 
-// subscribe and non-subscribe requests. 
+// CreateHttpClient creates the http.Client by creating or reusing the transports of the package's
+// default instance for subscribe and non-subscribe requests.
+//
+// Deprecated: use the CreateHttpClient method of a Pubnub instance.
+func CreateHttpClient (isSubscribe bool) (*http.Client, error) {
+    return getDefaultPubnub().CreateHttpClient(isSubscribe)
+}
+
+// CreateHttpClient is the struct Pubnub's instance method that creates the http.Client by 
+// creating or reusing the instance's transport for subscribe and non-subscribe requests. 
+//
+// If the instance's Config has a HttpClient it is returned as is, 
+// else if the Config has a Transport it is used instead of the built-in transports.
 // 
 // It accepts the following parameters:
 // isSubscribe: true if it is a subscribe request.
@@ -1707,22 +2001,28 @@ func SetOrGetTransport(isSubscribe bool) (http.RoundTripper){
 // returns:
 // the pointer to the http.Client
 // error is any.   
-func CreateHttpClient (isSubscribe bool) (*http.Client, error) {
+func (pub *Pubnub) CreateHttpClient (isSubscribe bool) (*http.Client, error) {
+    if (pub.config.HttpClient != nil){
+        return pub.config.HttpClient, nil
+    }
+    if (pub.config.Transport != nil){
+        return &http.Client{Transport: pub.config.Transport, CheckRedirect: nil}, nil
+    }
     var transport http.RoundTripper
     
+    pub.connLock.Lock()
     if (isSubscribe){
-        if (_subscribeTransport == nil){
-            trans := SetOrGetTransport(isSubscribe)
-            _subscribeTransport = trans
+        if (pub.subscribeTransport == nil){
+            pub.subscribeTransport = pub.SetOrGetTransport(isSubscribe)
         }
-        transport = _subscribeTransport
+        transport = pub.subscribeTransport
     }else{
-        if (_transport == nil){
-            trans := SetOrGetTransport(isSubscribe)
-            _transport = trans
+        if (pub.transport == nil){
+            pub.transport = pub.SetOrGetTransport(isSubscribe)
         }
-        transport = _transport
+        transport = pub.transport
     }
+    pub.connLock.Unlock()
     
     var err error
     var httpClient *http.Client
@@ -1734,10 +2034,21 @@ func CreateHttpClient (isSubscribe bool) (*http.Client, error) {
     return httpClient, err
 }
 
-// Connect creates a http request to the pubnub origin and returns the 
-// response or the error while connecting. 
+// Connect creates a http request to the pubnub origin with the http.Client of the package's
+// default instance and returns the response or the error while connecting.
+//
+// Deprecated: use the Connect method of a Pubnub instance.
+func Connect (requestUrl string, isSubscribe bool) ([]byte, int, error) {
+    return getDefaultPubnub().Connect(context.Background(), requestUrl, isSubscribe)
+}
+
+// Connect is the struct Pubnub's instance method that creates a http request to the pubnub origin 
+// and returns the response or the error while connecting. 
+// The request is bound to the context returned by CreateRequestContext, so it can be cancelled
+// by the ctx, CloseExistingConnection or Abort even if the http.Client is provided by the user.
 // 
 // It accepts the following parameters:
+// ctx: the parent context of the request.
 // requestUrl: the url to connect to.
 // isSubscribe: true if it is a subscribe request.
 //
@@ -1745,15 +2056,56 @@ func CreateHttpClient (isSubscribe bool) (*http.Client, error) {
 // the response as byte array.
 // response errorcode if any.
 // error if any.  
-func Connect (requestUrl string, isSubscribe bool) ([]byte, int, error) {
+func (pub *Pubnub) Connect (ctx context.Context, requestUrl string, isSubscribe bool) ([]byte, int, error) {
+    return pub.ConnectWithBody(ctx, "GET", requestUrl, nil, isSubscribe)
+}
+
+// ConnectWithBody is the variant of Connect that sends the request with the http method and, 
+// if not nil, the body with the "application/json" content type.
+// 
+// It accepts the following parameters:
+// ctx: the parent context of the request.
+// method: the http method, e.g. "GET" or "POST".
+// requestUrl: the url to connect to.
+// body: the json body of the request, nil if none.
+// isSubscribe: true if it is a subscribe request.
+//
+// returns:
+// the response as byte array.
+// response errorcode if any.
+// error if any.  
+func (pub *Pubnub) ConnectWithBody (ctx context.Context, method string, requestUrl string, body []byte, isSubscribe bool) ([]byte, int, error) {
+    return pub.connect(ctx, method, requestUrl, body, isSubscribe, true)
+}
+
+// connect is ConnectWithBody, if readBody is false it returns once the response headers are 
+// received without the contents, the body is then drained and closed in the background.
+func (pub *Pubnub) connect (ctx context.Context, method string, requestUrl string, body []byte, isSubscribe bool, readBody bool) ([]byte, int, error) {
     var contents []byte
-    httpClient, err := CreateHttpClient(isSubscribe)
+    httpClient, err := pub.CreateHttpClient(isSubscribe)
     
     if(err == nil) {
-        req, err := http.NewRequest("GET", requestUrl, nil) 
+        var bodyReader io.Reader
+        if(body != nil){
+            bodyReader = bytes.NewReader(body)
+        }
+        req, err := http.NewRequest(method, requestUrl, bodyReader) 
         if(err == nil) {
-            response, err := httpClient.Do(req)  
+            if(body != nil){
+                req.Header.Set("Content-Type", "application/json")
+            }
+            requestCtx, cancel := pub.CreateRequestContext(ctx, isSubscribe)
+            response, err := httpClient.Do(req.WithContext(requestCtx))  
              if (err == nil) {
+                if(!readBody){
+                    go func(){
+                        io.Copy(ioutil.Discard, response.Body)
+                        response.Body.Close()
+                        cancel()
+                    }()
+                    return nil, response.StatusCode, nil
+                }
+                defer cancel()
                 defer response.Body.Close()
                 bodyContents, e := ioutil.ReadAll(response.Body)
                 if(e == nil){
@@ -1763,6 +2115,7 @@ func Connect (requestUrl string, isSubscribe bool) ([]byte, int, error) {
                     return nil, response.StatusCode, e
                 }
             }else {
+                cancel()
                 if(response!=nil){
                     return nil, response.StatusCode, err    
                 } else {
@@ -1777,6 +2130,37 @@ func Connect (requestUrl string, isSubscribe bool) ([]byte, int, error) {
     return nil, 0, err
 }
 
+// CreateRequestContext is the struct Pubnub's instance method that derives the context 
+// of a http request from the parent ctx with the subscribe or non-subscribe timeout of the instance's Config.
+// The cancel func of a subscribe request is stored so that CloseExistingConnection can cancel it.
+// The non-subscribe requests are also cancelled when the abortContext is cancelled by Abort.
+//
+// It accepts the following parameters:
+// ctx: the parent context.
+// isSubscribe: true if it is a subscribe request.
+//
+// returns:
+// the context of the request.
+// the cancel func to release the context.
+func (pub *Pubnub) CreateRequestContext(ctx context.Context, isSubscribe bool) (context.Context, context.CancelFunc) {
+    pub.connLock.Lock()
+    defer pub.connLock.Unlock()
+    if (isSubscribe){
+        requestCtx, cancel := context.WithTimeout(ctx, time.Duration(pub.config.SubscribeTimeout) * time.Second)
+        pub.subscribeCancel = cancel
+        return requestCtx, cancel
+    }
+    if (pub.abortContext == nil){
+        pub.abortContext, pub.abortCancel = context.WithCancel(context.Background())
+    }
+    requestCtx, cancel := context.WithTimeout(ctx, time.Duration(pub.config.NonSubscribeTimeout) * time.Second)
+    stop := context.AfterFunc(pub.abortContext, cancel)
+    return requestCtx, func(){
+        stop()
+        cancel()
+    }
+}
+
 // PKCS7Padding pads the data as per the PKCS7 standard
 // It accepts the following parameters:
 // data: data to pad as byte array.
@@ -1800,6 +2184,28 @@ func PKCS7Padding(data []byte) []byte {
     return append(data, padding...)
 }
 
+// ValidPKCS7Padding checks the padding of the decrypted data, an invalid padding means
+// the data was not encrypted with the cipher key.
+// It accepts the following parameters:
+// data: the decrypted data as byte array.
+// returns true if the bytes of the padding removed by UnPKCS7Padding are all equal.
+func ValidPKCS7Padding(data []byte) bool {
+    dataLen := len(data)
+    if dataLen == 0 {
+        return true
+    }
+    endIndex := int(data[dataLen-1])
+    if (16 <= endIndex) || (endIndex > dataLen) {
+        return true
+    }
+    for i := dataLen - endIndex; i < dataLen; i++ {
+        if data[dataLen-1] != data[i] {
+            return false
+        }
+    }
+    return true
+}
+
 // UnPKCS7Padding unpads the data as per the PKCS7 standard
 // It accepts the following parameters:
 // data: data to unpad as byte array.
@@ -1811,13 +2217,6 @@ func UnPKCS7Padding(data []byte) []byte {
     }
     endIndex := int(data[dataLen-1])
     if 16 > endIndex {
-        if 1 < endIndex {
-            for i := dataLen - endIndex; i < dataLen; i++ {
-                if data[dataLen-1] != data[i] {
-                    fmt.Println(" : ", data[dataLen-1], " ：", i, "  ：", data[i])
-                }
-            }
-        }
         return data[:dataLen-endIndex]
     }
     return data
@@ -1908,22 +2307,25 @@ func EncryptString(cipherKey string, message string) string {
 func DecryptString(cipherKey string, message string) (retVal interface{}, err error) { 
     block, aesErr := AesCipher(cipherKey)
     if(aesErr != nil){
-        return "***Decrypt Error***", fmt.Errorf("Decrypt error aes cipher: ", aesErr) 
+        return "***Decrypt Error***", &DecryptError{Message: "aes cipher: " + aesErr.Error()}
     }
     
     value, decodeErr := base64.StdEncoding.DecodeString(message)
     if(decodeErr != nil){
-        return "***Decrypt Error***", fmt.Errorf("Decrypt error on decode: ", decodeErr) 
+        return "***Decrypt Error***", &DecryptError{Message: "on decode: " + decodeErr.Error()}
     }
     decrypter := cipher.NewCBCDecrypter(block, []byte(_IV))
     //to handle decryption errors
     defer func(){
         if r := recover(); r != nil {
-            retVal, err = "***Decrypt Error***", fmt.Errorf("Decrypt error:", r)
+            retVal, err = "***Decrypt Error***", &DecryptError{Message: fmt.Sprintf("%v", r)}
         }
     }()
     decrypted := make([]byte, len(value))
     decrypter.CryptBlocks(decrypted, value)
+    if(!ValidPKCS7Padding(decrypted)){
+        return "***Decrypt Error***", &DecryptError{Message: "invalid padding"}
+    }
     return fmt.Sprintf("%s", string(UnPKCS7Padding(decrypted))), nil
 }
 
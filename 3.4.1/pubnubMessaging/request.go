@@ -0,0 +1,216 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// request.go contains the retries of the non subscribe requests.
+package pubnubMessaging
+
+import (
+    "context"
+    "strconv"
+    "time"
+)
+
+// The query parameter of the client generated id of a published message.
+const _messageIdParameter = "messageid"
+
+// DefaultRetryableErrors returns the error categories retried by default for each operation.
+// Publish is retried only when the request wasn't sent, ErrorCategoryConnectFailed: after a timeout or
+// a connection closed once the request was sent the origin may have stored the message, and the
+// messageid of the retries isn't used by the origin to drop the duplicates.
+//
+// returns the error categories of each operation.
+func DefaultRetryableErrors() map[string][]string {
+    return map[string][]string{
+        OperationPublish:   []string{ErrorCategoryConnectFailed},
+        OperationHistory:   []string{ErrorCategoryNetworkUnavailable, ErrorCategoryTimeout, ErrorCategoryInvalidJson},
+        OperationHereNow:   []string{ErrorCategoryNetworkUnavailable, ErrorCategoryTimeout, ErrorCategoryInvalidJson},
+        OperationTime:      []string{ErrorCategoryNetworkUnavailable, ErrorCategoryTimeout, ErrorCategoryInvalidJson},
+    }
+}
+
+// IsRetryable is the struct Pubnub's instance method that returns true if the error is one of
+// the RetryableErrors of the operation in the instance's Config. ErrorCategoryConnectFailed
+// matches the errors of the requests that weren't sent, whatever their category.
+//
+// It accepts the following parameters:
+// operation: the operation of the request, e.g. OperationPublish.
+// err: the error of the request.
+func (pub *Pubnub) IsRetryable(operation string, err error) bool {
+    if(err == nil){
+        return false
+    }
+    category := ErrorCategory(err)
+    for _, retryable := range pub.config.RetryableErrors[operation] {
+        if((retryable == category) || ((retryable == ErrorCategoryConnectFailed) && IsConnectError(err))){
+            return true
+        }
+    }
+    return false
+}
+
+// RetryRequest is the struct Pubnub's instance method that sends a non subscribe request and
+// sends it again while it fails with one of the RetryableErrors of the operation. The delay before
+// each retry and the number of retries are decided by the RequestRetryPolicy of the instance's Config.
+// No retry is made once the ctx is done.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// operation: the operation of the request, e.g. OperationPublish.
+// channel: the pubnub channel of the request, used in the log entries.
+// request: sends the request once and returns the response contents, the response code and the
+// error, including the non 200 responses and the invalid json.
+//
+// returns the response contents, the response code and the error of the last attempt.
+func (pub *Pubnub) RetryRequest(ctx context.Context, operation string, channel string, request func() ([]byte, int, error)) ([]byte, int, error) {
+    for attempt := 1; ; attempt++ {
+        value, responseCode, err := pub.TrackRequest(operation, channel, request)
+        if((err == nil) || (ctx.Err() != nil) || !pub.IsRetryable(operation, err)){
+            return value, responseCode, err
+        }
+        delay, ok := pub.config.RequestRetryPolicy.Delay(attempt)
+        if(!ok){
+            return value, responseCode, err
+        }
+        pub.config.Metrics.RequestRetried(operation, attempt)
+        pub.Log(LogLevelInfo, "retrying request", LogFields{Operation: operation, Channel: channel, StatusCode: responseCode, Duration: delay, RetryCount: attempt, Err: err})
+        timer := time.NewTimer(delay)
+        select {
+            case <-timer.C:
+            case <-ctx.Done():
+                timer.Stop()
+                return value, responseCode, err
+        }
+    }
+}
+
+// CheckResponse is the struct Pubnub's instance method that returns the error of a non subscribe 
+// response: the error of the request, a typed error of the status code if it is not 200, or the 
+// InvalidJsonError.
+//
+// It accepts the following parameters:
+// value: the response contents.
+// responseCode: the response code.
+// err: the error of the request.
+// failedMessage: the message of the error if the status code is not 200 and the response has none.
+//
+// returns the response contents, the response code and the error.
+func (pub *Pubnub) CheckResponse(value []byte, responseCode int, err error, failedMessage string) ([]byte, int, error) {
+    if (err != nil) {
+        return value, responseCode, err
+    }
+    if (responseCode != 200) {
+        return value, responseCode, CreateStatusCodeError(failedMessage, responseCode, value)
+    }
+    if _, _, _, errJson := ParseJson(value, pub.CipherKey); errJson != nil {
+        return value, responseCode, errJson
+    }
+    return value, responseCode, nil
+}
+
+// RequestTime is the struct Pubnub's instance method that sends the time request with retries.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+//
+// returns the response contents, the response code and the error.
+func (pub *Pubnub) RequestTime(ctx context.Context) ([]byte, int, error) {
+    return pub.RetryRequest(ctx, OperationTime, "", func() ([]byte, int, error) {
+        value, responseCode, err := pub.SendRequestUrl(ctx, OperationTime, "", pub.CreateTimeUrl(), false)
+        return pub.CheckResponse(value, responseCode, err, "Time Failed")
+    })
+}
+
+// RequestHistory is the struct Pubnub's instance method that sends the History request with retries.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: a single value of the pubnub channel.
+// limit: number of history messages to return.
+// start: start time from where to begin the history messages.
+// end: end time till where to get the history messages.
+// reverse: to fetch the messages in ascending order
+//
+// returns the response contents, the response code and the error.
+func (pub *Pubnub) RequestHistory(ctx context.Context, channel string, limit int, start int64, end int64, reverse bool) ([]byte, int, error) {
+    return pub.RetryRequest(ctx, OperationHistory, channel, func() ([]byte, int, error) {
+        value, responseCode, err := pub.SendRequestUrl(ctx, OperationHistory, channel, pub.CreateHistoryUrl(channel, limit, start, end, reverse), false)
+        return pub.CheckResponse(value, responseCode, err, "History Failed")
+    })
+}
+
+// RequestHereNow is the struct Pubnub's instance method that sends the herenow request with retries.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: a single value of the pubnub channel.
+//
+// returns the response contents, the response code and the error.
+func (pub *Pubnub) RequestHereNow(ctx context.Context, channel string) ([]byte, int, error) {
+    return pub.RetryRequest(ctx, OperationHereNow, channel, func() ([]byte, int, error) {
+        value, responseCode, err := pub.SendRequestUrl(ctx, OperationHereNow, channel, pub.CreateHereNowUrl(channel), false)
+        return pub.CheckResponse(value, responseCode, err, "HereNow Failed")
+    })
+}
+
+// RequestPublish is the struct Pubnub's instance method that sends the publish request with retries.
+// All the attempts are sent with the same client generated message id. The published message is
+// reported to the MetricsCollector of the instance's Config. With the SkipResponseBody of the options
+// a 200 response is not parsed, the response contents are [1,"Sent"].
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: pubnub channel to publish to.
+// publishUrlString: The url to which the message is to be appended.
+// jsonBytes: the message to be sent.
+// options: the options of the publish request.
+//
+// returns the response contents, the response code and the error.
+func (pub *Pubnub) RequestPublish(ctx context.Context, channel string, publishUrlString string, jsonBytes []byte, options PublishOptions) ([]byte, int, error) {
+    value, responseCode, err := pub.RetryRequest(ctx, OperationPublish, channel, func() ([]byte, int, error) {
+        value, responseCode, err := pub.ExecutePublishRequest(ctx, channel, publishUrlString, jsonBytes, options)
+        if (options.SkipResponseBody && (err == nil) && (responseCode == 200)) {
+            return []byte(_publishSentResponse), responseCode, nil
+        }
+        message := _publishFailed
+        if (len(value) > 0) {
+            message = string(value)
+        }
+        return pub.CheckResponse(value, responseCode, err, message)
+    })
+    if (err == nil) {
+        pub.config.Metrics.MessagePublished(channel)
+    }
+    return value, responseCode, err
+}
+
+// ExecutePublishRequest is the struct Pubnub's instance method that creates the publish request
+// with CreatePublishRequest and sends it once with SendRequest.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: pubnub channel to publish to.
+// publishUrlString: The url to which the message is to be appended.
+// jsonBytes: the message to be sent.
+// options: the options of the publish request.
+//
+// returns:
+// the HttpRequest response contents as byte array.
+// response error code,
+// error if any.
+func (pub *Pubnub) ExecutePublishRequest(ctx context.Context, channel string, publishUrlString string, jsonBytes []byte, options PublishOptions) ([]byte, int, error) {
+    request, urlErr := pub.CreatePublishRequest(channel, publishUrlString, jsonBytes, options)
+    if urlErr != nil {
+        return nil, 0, urlErr
+    }
+    return pub.SendRequest(ctx, request)
+}
+
+// CreateMessageId creates the client generated id of a published message, sent as the messageid
+// query parameter of the publish url.
+//
+// returns the id.
+func CreateMessageId() string {
+    messageId, err := GenUuid()
+    if (err != nil) || (messageId == "") {
+        return strconv.FormatInt(time.Now().UnixNano(), 16)
+    }
+    return messageId
+}
@@ -0,0 +1,112 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// retry.go contains the retry policies of the Subscribe/Presence reconnects.
+package pubnubMessaging
+
+import (
+    "math"
+    "math/rand"
+    "time"
+)
+
+// UnlimitedRetries is the MaxRetries of a retry policy that never gives up reconnecting.
+const UnlimitedRetries = -1
+
+// RetryPolicy decides the delay before each reconnect attempt of the Subscribe/Presence
+// subscriptions of a Pubnub instance, set with the RetryPolicy of the Config.
+//
+// The policies keep no state, the number of attempts is counted by each instance and is reset
+// once the instance reconnects, so a policy can be shared by many instances.
+type RetryPolicy interface {
+    // Delay returns the delay before the reconnect attempt, the attempts are counted from 1.
+    // It returns false if no more attempts should be made, the subscriptions are then
+    // closed with a MaxRetriesReached status.
+    Delay(attempt int) (time.Duration, bool)
+}
+
+// LinearRetryPolicy is the RetryPolicy that waits the same Interval before each attempt.
+//
+// Interval is the delay before each reconnect attempt.
+// MaxRetries is the number of attempts made before giving up, UnlimitedRetries to never give up.
+type LinearRetryPolicy struct {
+    Interval     time.Duration
+    MaxRetries   int
+}
+
+// NewLinearRetryPolicy creates a LinearRetryPolicy.
+//
+// It accepts the following parameters:
+// interval: the delay before each reconnect attempt.
+// maxRetries: the number of attempts made before giving up, UnlimitedRetries to never give up.
+//
+// returns the pointer to the LinearRetryPolicy.
+func NewLinearRetryPolicy(interval time.Duration, maxRetries int) *LinearRetryPolicy {
+    return &LinearRetryPolicy{
+        Interval:     interval,
+        MaxRetries:   maxRetries,
+    }
+}
+
+// Delay returns the Interval, false once the MaxRetries attempts were made.
+func (policy LinearRetryPolicy) Delay(attempt int) (time.Duration, bool) {
+    if(RetriesExhausted(attempt, policy.MaxRetries)){
+        return 0, false
+    }
+    return policy.Interval, true
+}
+
+// ExponentialRetryPolicy is the RetryPolicy that doubles the delay after each attempt, from
+// MinDelay up to MaxDelay. A random jitter of up to half of the delay is subtracted from each
+// delay so that many clients disconnected together don't reconnect in lockstep.
+//
+// MinDelay is the delay before the first reconnect attempt.
+// MaxDelay is the upper bound of the delay.
+// MaxRetries is the number of attempts made before giving up, UnlimitedRetries to never give up.
+type ExponentialRetryPolicy struct {
+    MinDelay     time.Duration
+    MaxDelay     time.Duration
+    MaxRetries   int
+}
+
+// NewExponentialRetryPolicy creates an ExponentialRetryPolicy.
+//
+// It accepts the following parameters:
+// minDelay: the delay before the first reconnect attempt.
+// maxDelay: the upper bound of the delay.
+// maxRetries: the number of attempts made before giving up, UnlimitedRetries to never give up.
+//
+// returns the pointer to the ExponentialRetryPolicy.
+func NewExponentialRetryPolicy(minDelay time.Duration, maxDelay time.Duration, maxRetries int) *ExponentialRetryPolicy {
+    return &ExponentialRetryPolicy{
+        MinDelay:     minDelay,
+        MaxDelay:     maxDelay,
+        MaxRetries:   maxRetries,
+    }
+}
+
+// Delay returns the MinDelay doubled for each previous attempt, capped at MaxDelay, minus a
+// random jitter of up to half of it. Returns false once the MaxRetries attempts were made.
+func (policy ExponentialRetryPolicy) Delay(attempt int) (time.Duration, bool) {
+    if(RetriesExhausted(attempt, policy.MaxRetries)){
+        return 0, false
+    }
+    delay := policy.MinDelay
+    for i := 1; i < attempt; i++ {
+        if(((policy.MaxDelay > 0) && (delay >= policy.MaxDelay)) || (delay > math.MaxInt64 / 2)){
+            break
+        }
+        delay *= 2
+    }
+    if((policy.MaxDelay > 0) && (delay > policy.MaxDelay)){
+        delay = policy.MaxDelay
+    }
+    if(delay <= 1){
+        return delay, true
+    }
+    return delay - time.Duration(rand.Int63n(int64(delay / 2))), true
+}
+
+// RetriesExhausted returns true if the attempt exceeds the maxRetries, never if maxRetries is
+// UnlimitedRetries or any other negative value.
+func RetriesExhausted(attempt int, maxRetries int) bool {
+    return (maxRetries >= 0) && (attempt > maxRetries)
+}
@@ -0,0 +1,269 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// status.go contains the status events of the Subscribe/Presence subscriptions.
+package pubnubMessaging
+
+import (
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// StatusCategory is the category of a StatusEvent.
+type StatusCategory string
+
+// The categories of the StatusEvent.
+const (
+    StatusConnected StatusCategory = "Connected"
+    StatusReconnected StatusCategory = "Reconnected"
+    StatusDisconnected StatusCategory = "Disconnected"
+    StatusUnsubscribed StatusCategory = "Unsubscribed"
+    StatusAlreadySubscribed StatusCategory = "AlreadySubscribed"
+    StatusNotSubscribed StatusCategory = "NotSubscribed"
+    StatusMaxRetriesReached StatusCategory = "MaxRetriesReached"
+    StatusTimeout StatusCategory = "Timeout"
+)
+
+// StatusEvent is a change in the state of the Subscribe/Presence subscriptions of a Pubnub instance.
+//
+// Category is the kind of the change.
+// Channels are the affected pubnub channels.
+// PresenceChannels are the affected pubnub channels of the presence subscriptions,
+// without the "-pnpres" suffix.
+// RetryCount is the number of reconnect attempts made so far.
+type StatusEvent struct {
+    Category           StatusCategory
+    Channels           []string
+    PresenceChannels   []string
+    RetryCount         int
+}
+
+// CreateStatusEvent creates the StatusEvent of the comma separated pubnub channels,
+// the channels suffixed with "-pnpres" are added to the PresenceChannels.
+//
+// It accepts the following parameters:
+// category: the category of the event.
+// channels: Pubnub Channels affected. Comma separated string for multiple channels.
+// retryCount: the number of reconnect attempts made so far.
+//
+// returns the StatusEvent.
+func CreateStatusEvent(category StatusCategory, channels string, retryCount int) StatusEvent {
+    event := StatusEvent{
+        Category:     category,
+        RetryCount:   retryCount,
+    }
+    channelArray := strings.Split(channels, ",")
+    for i := 0; i < len(channelArray); i++ {
+        channel := strings.TrimSpace(channelArray[i])
+        if(channel == ""){
+            continue
+        }
+        if(strings.HasSuffix(channel, _presenceSuffix)){
+            event.PresenceChannels = append(event.PresenceChannels, strings.Replace(channel, _presenceSuffix, "", -1))
+        } else {
+            event.Channels = append(event.Channels, channel)
+        }
+    }
+    return event
+}
+
+// SetStatusChannel is the struct Pubnub's instance method that sets the channel on which
+// the StatusEvents of the instance are sent. No StatusEvent is sent if it is nil.
+//
+// The events are sent from the subscribe loop without waiting for the reader, so the channel
+// should be buffered. An event that doesn't fit in the buffer is dropped and logged.
+//
+// It accepts the following parameters:
+// statusChannel: the channel of the status events.
+func (pub *Pubnub) SetStatusChannel(statusChannel chan StatusEvent) {
+    pub.connLock.Lock()
+    defer pub.connLock.Unlock()
+    pub.statusChannel = statusChannel
+}
+
+// SendStatus is the struct Pubnub's instance method that sends the StatusEvent of the channels on
+// the status channel of the instance and to the Listeners added with AddListener.
+//
+// For the existing code the status is also sent as json, e.g.
+// [1, "Subscription to channel 'my_channel' connected", "my_channel"], to the channel c.
+//
+// It accepts the following parameters:
+// c: Channel on which to send the json response. Can be nil. If nil the StatusEvent of each of the
+// pubnub channels is sent to the Listeners of the channel instead.
+// category: the category of the event.
+// channels: Pubnub Channels affected. Comma separated string for multiple channels.
+// retryCount: the number of reconnect attempts made so far.
+func (pub *Pubnub) SendStatus(c chan []byte, category StatusCategory, channels string, retryCount int) {
+    var listener Listener
+    if(c != nil){
+        listener = &ChannelListener{CallbackChannel: c, ErrorChannel: c}
+    }
+    pub.SendStatusToListener(listener, category, channels, retryCount)
+}
+
+// SendStatusToListener is the struct Pubnub's instance method that sends the StatusEvent of the channels
+// on the status channel of the instance and to the Listeners added with AddListener.
+//
+// It accepts the following parameters:
+// listener: the Listener to send the StatusEvent to. Can be nil. If nil the StatusEvent of each of the
+// pubnub channels is sent to the Listeners of the channel instead.
+// category: the category of the event.
+// channels: Pubnub Channels affected. Comma separated string for multiple channels.
+// retryCount: the number of reconnect attempts made so far.
+func (pub *Pubnub) SendStatusToListener(listener Listener, category StatusCategory, channels string, retryCount int) {
+    event := CreateStatusEvent(category, channels, retryCount)
+    if((len(event.Channels) == 0) && (len(event.PresenceChannels) == 0)){
+        return
+    }
+    pub.connLock.Lock()
+    statusChannel := pub.statusChannel
+    pub.connLock.Unlock()
+    if(statusChannel != nil){
+        select {
+            case statusChannel <- event:
+            default:
+                pub.Log(LogLevelWarn, "status event dropped", LogFields{Operation: OperationSubscribe, Channel: channels, RetryCount: retryCount})
+        }
+    }
+
+    listeners := pub.GetListeners("")
+    for _, l := range listeners {
+        l.OnStatus(event)
+    }
+    if(listener != nil){
+        if(IndexOfListener(listeners, listener) < 0){
+            listener.OnStatus(event)
+        }
+        return
+    }
+    channelArray := strings.Split(channels, ",")
+    for i := 0; i < len(channelArray); i++ {
+        channel := strings.TrimSpace(channelArray[i])
+        if(channel == ""){
+            continue
+        }
+        channelEvent := CreateStatusEvent(category, channel, retryCount)
+        for _, l := range pub.GetListeners(channel) {
+            if(IndexOfListener(listeners, l) < 0){
+                l.OnStatus(channelEvent)
+            }
+        }
+    }
+}
+
+// CreateStatusResponse creates the json response of a status event of the pubnub channel.
+//
+// It accepts the following parameters:
+// category: the category of the event.
+// channel: the pubnub channel, suffixed with "-pnpres" for a presence subscription.
+// retryCount: the number of reconnect attempts made so far.
+//
+// returns the json response.
+func CreateStatusResponse(category StatusCategory, channel string, retryCount int) string {
+    presence := "Subscription to channel "
+    if(strings.HasSuffix(channel, _presenceSuffix)){
+        presence = "Presence notifications for channel "
+        channel = strings.Replace(channel, _presenceSuffix, "", -1)
+    }
+    switch category {
+        case StatusConnected:
+            return fmt.Sprintf("[1, \"%s'%s' connected\", \"%s\"]", presence, channel, channel)
+        case StatusReconnected:
+            return fmt.Sprintf("[1, \"%s'%s' reconnected\", \"%s\"]", presence, channel, channel)
+        case StatusUnsubscribed:
+            return fmt.Sprintf("[1, \"%s'%s' unsubscribed\", \"%s\"]", presence, channel, channel)
+        case StatusAlreadySubscribed:
+            return fmt.Sprintf("[0, \"%s'%s' already subscribed\", \"%s\"]", presence, channel, channel)
+        case StatusNotSubscribed:
+            return fmt.Sprintf("[0, \"%s'%s' not subscribed\", \"%s\"]", presence, channel, channel)
+        case StatusDisconnected:
+            return fmt.Sprintf("[0, \"%sdisconnected due to internet connection issues, trying to reconnect. Retry count:%s\", \"%s\"]", presence, strconv.Itoa(retryCount), channel)
+        case StatusMaxRetriesReached:
+            return fmt.Sprintf("[0, \"%saborted due to max retry limit\", \"%s\"]", presence, channel)
+        case StatusTimeout:
+            return fmt.Sprintf("[0, \"%stimed out.\", \"%s\"]", presence, channel)
+    }
+    return fmt.Sprintf("[0, \"%s'%s' %s\", \"%s\"]", presence, channel, category, channel)
+}
+
+// SendRawResponseToChannel is the struct Pubnub's instance method that sends the response as is,
+// with the "-pnpres" suffixes removed, to the callback channel of the ChannelListeners of each of
+// the pubnub channels.
+//
+// It accepts the following parameters:
+// c: Channel on which to send the response. Can be nil. If nil the callback channels of the
+// ChannelListeners of each of the pubnub channels are used.
+// channels: Pubnub Channels of the response. Comma separated string for multiple channels.
+// response: the response to send.
+func (pub *Pubnub) SendRawResponseToChannel(c chan []byte, channels string, response string) {
+    value := []byte(strings.Replace(response, _presenceSuffix, "", -1))
+    channelArray := strings.Split(channels, ",")
+    for i := 0; i < len(channelArray); i++ {
+        channel := strings.TrimSpace(channelArray[i])
+        if(channel == ""){
+            continue
+        }
+        if(c != nil){
+            c <- value
+            continue
+        }
+        for _, l := range pub.GetListeners(channel) {
+            if channelListener, ok := l.(*ChannelListener); ok && (channelListener.CallbackChannel != nil) {
+                channelListener.CallbackChannel <- value
+            }
+        }
+    }
+}
+
+// SendResponseToChannel is the struct Pubnub's instance method that sends a response on the channel
+// provided as an argument or to the Listeners of the pubnub channels if the argument is nil.
+//
+// The action (1-11) is mapped to a StatusCategory sent with SendStatus: 1 already subscribed,
+// 2 connected, 3 unsubscribed, 4 not subscribed, 6 reconnected, 7 disconnected, 8 max retries
+// reached and 11 timed out. The response of action 5 is sent as is with SendRawResponseToChannel,
+// the response of actions 9 and 10 is sent as a PubnubError with SendErrorToChannel.
+//
+// It accepts the following parameters:
+// c: Channel on which to send the response back. Can be nil.
+// channels: Pubnub Channels to send a response to. Comma separated string for multiple channels.
+// action: (1-11)
+// response: the response of actions 5, 9 and 10, the retry count of action 7.
+// response2: the http status code of actions 9 and 10, can be empty.
+func (pub *Pubnub) SendResponseToChannel(c chan []byte, channels string, action int, response string, response2 string) {
+    retryCount := pub.subscription.getRetryCount()
+    var category StatusCategory
+    switch action {
+        case 1:
+            category = StatusAlreadySubscribed
+        case 2:
+            category = StatusConnected
+        case 3:
+            category = StatusUnsubscribed
+        case 4:
+            category = StatusNotSubscribed
+        case 5:
+            pub.SendRawResponseToChannel(c, channels, response)
+            return
+        case 6:
+            category = StatusReconnected
+        case 7:
+            category = StatusDisconnected
+            if count, err := strconv.Atoi(response); err == nil {
+                retryCount = count
+            }
+        case 8:
+            category = StatusMaxRetriesReached
+        case 9, 10:
+            if(action == 10){
+                channels = ""
+            }
+            statusCode, _ := strconv.Atoi(response2)
+            pub.SendErrorToChannel(c, OperationSubscribe, channels, statusCode, errors.New(response))
+            return
+        case 11:
+            category = StatusTimeout
+        default:
+            return
+    }
+    pub.SendStatus(c, category, channels, retryCount)
+}
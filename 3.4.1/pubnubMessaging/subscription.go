@@ -0,0 +1,445 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// subscription.go contains the state of the Subscribe/Presence subscriptions.
+package pubnubMessaging
+
+import (
+    "context"
+    "strings"
+    "sync"
+    "time"
+)
+
+// subscriptionState is the single owner of the state of the Subscribe/Presence subscriptions of
+// a Pubnub instance. The state is only read and written through its methods, which hold the lock,
+// so Subscribe, Unsubscribe and the subscribe loop can be called from any number of goroutines.
+//
+// channels are the pubnub channels of the Subscribe subscriptions, presenceChannels of the Presence
+// subscriptions.
+// timeToken is the timetoken of the next subscribe request, sentTimeToken of the last one.
+// resetTimeToken is true if the next subscribe request is sent with the timetoken 0.
+// connectPending is true till the Connected status of the subscribed channels is sent.
+// retryCount is the number of reconnect attempts made so far.
+// lastCheckpoint is the time the timetoken was last saved to the CheckpointStore.
+// running is true while the StartSubscribeLoop of the instance is running, loopCtx is its context,
+// cancelled by loopCancel once no channel remains subscribed.
+// listeners are the Listeners added with AddListener, channelListeners the Listeners of each
+// subscription.
+type subscriptionState struct {
+    lock                sync.Mutex
+    channels            ChannelSet
+    presenceChannels    ChannelSet
+    timeToken           string
+    sentTimeToken       string
+    resetTimeToken      bool
+    connectPending      bool
+    retryCount          int
+    lastCheckpoint      time.Time
+    running             bool
+    loopCtx             context.Context
+    loopCancel          context.CancelFunc
+    listeners           []Listener
+    channelListeners    map[string] []Listener
+}
+
+// newSubscriptionState creates the state of an instance without any subscription.
+func newSubscriptionState() *subscriptionState {
+    return &subscriptionState{
+        timeToken:          "0",
+        sentTimeToken:      "0",
+        resetTimeToken:     true,
+        channelListeners:   make(map[string] []Listener),
+    }
+}
+
+// getSet returns the set of the Subscribe or the Presence subscriptions, the lock must be held.
+func (s *subscriptionState) getSet(isPresenceSubscribe bool) *ChannelSet {
+    if(isPresenceSubscribe){
+        return &s.presenceChannels
+    }
+    return &s.channels
+}
+
+// join returns the subscribed channels as a comma separated string, the presence channels
+// suffixed with "-pnpres". The lock must be held.
+func (s *subscriptionState) join() string {
+    channels := s.channels.String()
+    if(s.presenceChannels.Len() > 0){
+        if len(channels)>0 {
+            channels += ","
+        }
+        channels += s.presenceChannels.Join(_presenceSuffix)
+    }
+    return channels
+}
+
+// add appends the channels that are not subscribed yet to the subscribed channels. If any is
+// appended the next subscribe request is sent with the timetoken, or with 0 if it is empty.
+// If the subscribe loop is not running, or is stopping, the context of a new loop is created with
+// the values of the ctx, it is cancelled once no channel remains subscribed.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscription.
+// channels: comma separated pubnub channel list.
+// isPresenceSubscribe: true for a presence subscription.
+// timetoken: the timetoken of the next subscribe request, can be empty.
+//
+// returns the channels already subscribed as a comma separated string,
+// the context of the subscribe loop to start, nil if it is running,
+// true if the channels were modified.
+func (s *subscriptionState) add(ctx context.Context, channels string, isPresenceSubscribe bool, timetoken string) (string, context.Context, bool) {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    alreadySubscribedChannels := ""
+    channelsModified := false
+    set := s.getSet(isPresenceSubscribe)
+    for _, u := range strings.Split(channels, ",") {
+        channelToSub := strings.TrimSpace(u)
+        if(channelToSub == ""){
+            continue
+        }
+        if(set.Add(channelToSub)){
+            channelsModified = true
+            continue
+        }
+        if(isPresenceSubscribe){
+            channelToSub += _presenceSuffix
+        }
+        if len(alreadySubscribedChannels)>0 {
+            alreadySubscribedChannels += ","
+        }
+        alreadySubscribedChannels += channelToSub
+    }
+    if(!channelsModified){
+        return alreadySubscribedChannels, nil, false
+    }
+    if(strings.TrimSpace(timetoken) != ""){
+        s.timeToken = timetoken
+        s.resetTimeToken = false
+    } else {
+        s.resetTimeToken = true
+    }
+    s.connectPending = true
+    return alreadySubscribedChannels, s.start(ctx), true
+}
+
+// start creates the context of a new subscribe loop with the values of the ctx if the loop is not
+// running, or is stopping. The lock must be held.
+//
+// returns the context of the subscribe loop to start, nil if it is running.
+func (s *subscriptionState) start(ctx context.Context) context.Context {
+    if(s.running && (s.loopCtx.Err() == nil)){
+        return nil
+    }
+    s.loopCtx, s.loopCancel = context.WithCancel(context.WithoutCancel(ctx))
+    s.running = true
+    return s.loopCtx
+}
+
+// startLoop creates the context of a new subscribe loop with the values of the ctx, it is
+// cancelled once no channel remains subscribed.
+//
+// returns the context of the subscribe loop to start, nil if it is running.
+func (s *subscriptionState) startLoop(ctx context.Context) context.Context {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    return s.start(ctx)
+}
+
+// stopLoopIfEmpty cancels the context of the subscribe loop if no channel is subscribed, so that
+// the loop stops without waiting for the end of its request or its pause. The lock must be held.
+func (s *subscriptionState) stopLoopIfEmpty() {
+    if((s.join() == "") && (s.loopCancel != nil)){
+        s.loopCancel()
+    }
+}
+
+// remove removes the channel and its Listeners from the subscription.
+//
+// It accepts the following parameters:
+// channel: the pubnub channel.
+// isPresenceSubscribe: true for a presence subscription.
+//
+// returns true if the channel was subscribed.
+func (s *subscriptionState) remove(channel string, isPresenceSubscribe bool) bool {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    channel = strings.TrimSpace(channel)
+    if(!s.getSet(isPresenceSubscribe).Remove(channel)){
+        return false
+    }
+    s.stopLoopIfEmpty()
+    if(isPresenceSubscribe){
+        channel += _presenceSuffix
+    }
+    delete(s.channelListeners, channel)
+    return true
+}
+
+// clear removes all the channels from the subscription, the subscribe loop stops on its next iteration.
+//
+// returns the removed channels as a comma separated string, the presence channels suffixed with "-pnpres".
+func (s *subscriptionState) clear() string {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    channels := s.join()
+    s.channels.Clear()
+    s.presenceChannels.Clear()
+    s.stopLoopIfEmpty()
+    return channels
+}
+
+// isSubscribed returns true if the channel is subscribed.
+//
+// It accepts the following parameters:
+// channel: the pubnub channel.
+// isPresenceSubscribe: true for a presence subscription.
+func (s *subscriptionState) isSubscribed(channel string, isPresenceSubscribe bool) bool {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    return s.getSet(isPresenceSubscribe).Contains(channel)
+}
+
+// getChannels returns the subscribed channels as a comma separated string, the presence channels
+// suffixed with "-pnpres".
+func (s *subscriptionState) getChannels() string {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    return s.join()
+}
+
+// getChannelList returns a copy of the channels of the Subscribe or the Presence subscriptions.
+func (s *subscriptionState) getChannelList(isPresenceSubscribe bool) []string {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    return s.getSet(isPresenceSubscribe).Channels()
+}
+
+// next returns the channels and the timetoken of the next subscribe request and stores the
+// timetoken as the sent one. If no channel is subscribed the subscribe loop is marked as stopped.
+//
+// It accepts the following parameters:
+// ctx: the context of the subscribe loop.
+//
+// returns the channels, the timetoken and false if no channel is subscribed or a new loop
+// replaced the loop of the ctx.
+func (s *subscriptionState) next(ctx context.Context) (string, string, bool) {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    if(ctx != s.loopCtx){
+        return "", "", false
+    }
+    channels := s.join()
+    if(channels == ""){
+        s.running = false
+        s.loopCancel()
+        return "", "", false
+    }
+    return channels, s.send(), true
+}
+
+// send stores the timetoken of the next subscribe request as the sent one, 0 if it is reset.
+// The lock must be held.
+//
+// returns the sent timetoken.
+func (s *subscriptionState) send() string {
+    if(s.resetTimeToken){
+        s.resetTimeToken = false
+        s.sentTimeToken = "0"
+    } else {
+        if(strings.TrimSpace(s.timeToken) == ""){
+            s.timeToken = "0"
+        }
+        s.sentTimeToken = s.timeToken
+    }
+    return s.sentTimeToken
+}
+
+// nextRequest returns the channels and the timetoken of the next subscribe request and stores
+// the timetoken as the sent one.
+func (s *subscriptionState) nextRequest() (string, string) {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    return s.join(), s.send()
+}
+
+// setTimeToken sets the timetoken of the next subscribe request.
+func (s *subscriptionState) setTimeToken(timeToken string) {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    s.timeToken = timeToken
+}
+
+// resume sets the timetoken of the next subscribe request if it would be sent with the timetoken 0.
+//
+// returns true if the timetoken was set.
+func (s *subscriptionState) resume(timeToken string) bool {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    if(!s.resetTimeToken){
+        return false
+    }
+    s.timeToken = timeToken
+    s.resetTimeToken = false
+    return true
+}
+
+// checkpointDue returns true if the timetoken should be saved to the CheckpointStore, if so
+// the time of the last save is set to now.
+//
+// It accepts the following parameters:
+// now: the current time.
+// interval: the time between two saves.
+// force: true to save the timetoken whatever the time of the last save.
+func (s *subscriptionState) checkpointDue(now time.Time, interval time.Duration, force bool) bool {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    if(!force && (now.Sub(s.lastCheckpoint) < interval)){
+        return false
+    }
+    s.lastCheckpoint = now
+    return true
+}
+
+// setResetTimeToken makes the next subscribe request be sent with the timetoken 0.
+func (s *subscriptionState) setResetTimeToken() {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    s.resetTimeToken = true
+    s.connectPending = true
+}
+
+// connected is called on each successful subscribe response, whatever the timetoken sent.
+//
+// returns true once after the channels were modified or the timetoken reset,
+// the Connected status should then be sent.
+func (s *subscriptionState) connected() bool {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    if(!s.connectPending){
+        return false
+    }
+    s.connectPending = false
+    return true
+}
+
+// getRetryCount returns the number of reconnect attempts made so far.
+func (s *subscriptionState) getRetryCount() int {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    return s.retryCount
+}
+
+// incrementRetryCount counts a reconnect attempt.
+//
+// returns the number of the attempt.
+func (s *subscriptionState) incrementRetryCount() int {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    s.retryCount++
+    return s.retryCount
+}
+
+// resetRetryCount resets the number of reconnect attempts.
+func (s *subscriptionState) resetRetryCount() {
+    s.lock.Lock()
+    defer s.lock.Unlock()
+    s.retryCount = 0
+}
+
+// GetSubscribedChannels is the struct Pubnub's instance method that appends the new channels to
+// the subscribed channels without subscribing to them.
+//
+// It splits the Pubnub channels in the parameter by a comma and compares them to the existing
+// subscriptions. The channels already subscribed are sent an AlreadySubscribed status.
+//
+// It accepts the following parameters:
+// channels: comma separated pubnub channel list.
+// callbackChannel: unused, kept for compatibility.
+// isPresenceSubscribe: true to compare the channels to the Presence subscriptions.
+// errorChannel: channel to send the AlreadySubscribed response to. Can be nil. If nil the status is
+// sent to the Listeners of the channels.
+//
+// Returns:
+// subChannels: the subscribed channels followed by the new channels as a comma separated string,
+// the presence channels suffixed with "-pnpres".
+// newSubChannels: the new channels as a comma separated string.
+// b: true if there is any new channel.
+func (pub *Pubnub) GetSubscribedChannels(channels string, callbackChannel chan []byte, isPresenceSubscribe bool, errorChannel chan []byte) (subChannels string, newSubChannels string, b bool) {
+    subscribedChannels := pub.subscription.getChannels()
+    newSubscribedChannels := ""
+    alreadySubscribedChannels := ""
+    for _, u := range strings.Split(channels, ",") {
+        channelToSub := strings.TrimSpace(u)
+        if(channelToSub == ""){
+            continue
+        }
+        subscribed := pub.subscription.isSubscribed(channelToSub, isPresenceSubscribe)
+        if(isPresenceSubscribe){
+            channelToSub += _presenceSuffix
+        }
+        if(subscribed){
+            if len(alreadySubscribedChannels)>0 {
+                alreadySubscribedChannels += ","
+            }
+            alreadySubscribedChannels += channelToSub
+            continue
+        }
+        if len(subscribedChannels)>0 {
+            subscribedChannels += ","
+        }
+        subscribedChannels += channelToSub
+        if len(newSubscribedChannels)>0 {
+            newSubscribedChannels += ","
+        }
+        newSubscribedChannels += channelToSub
+    }
+    if len(alreadySubscribedChannels)>0 {
+        pub.SendStatus(errorChannel, StatusAlreadySubscribed, alreadySubscribedChannels, pub.subscription.getRetryCount())
+    }
+    return subscribedChannels, newSubscribedChannels, (newSubscribedChannels != "")
+}
+
+// NotDuplicate is the struct Pubnub's instance method which checks for the channel name
+// in the existing subscriptions, a channel suffixed with "-pnpres" in the Presence subscriptions.
+//
+// It accepts the following parameters:
+// channel: the Pubnub channel name to check in the existing subscriptions.
+//
+// returns:
+// false if the channel is found.
+// true if not found.
+func (pub *Pubnub) NotDuplicate(channel string) (b bool) {
+    channel = strings.TrimSpace(channel)
+    if(strings.HasSuffix(channel, _presenceSuffix)){
+        return !pub.subscription.isSubscribed(strings.TrimSuffix(channel, _presenceSuffix), true)
+    }
+    return !pub.subscription.isSubscribed(channel, false)
+}
+
+// SubscribedChannels is the struct Pubnub's instance method that returns a copy of the pubnub
+// channels of the Subscribe subscriptions, in the order they were subscribed.
+func (pub *Pubnub) SubscribedChannels() []string {
+    return pub.subscription.getChannelList(false)
+}
+
+// PresenceChannels is the struct Pubnub's instance method that returns a copy of the pubnub
+// channels of the Presence subscriptions, without the "-pnpres" suffix, in the order they were subscribed.
+func (pub *Pubnub) PresenceChannels() []string {
+    return pub.subscription.getChannelList(true)
+}
+
+// TimeToken is the struct Pubnub's instance method that returns the timetoken of the next
+// subscribe request.
+func (pub *Pubnub) TimeToken() string {
+    pub.subscription.lock.Lock()
+    defer pub.subscription.lock.Unlock()
+    return pub.subscription.timeToken
+}
+
+// SentTimeToken is the struct Pubnub's instance method that returns the timetoken sent with
+// the last subscribe request.
+func (pub *Pubnub) SentTimeToken() string {
+    pub.subscription.lock.Lock()
+    defer pub.subscription.lock.Unlock()
+    return pub.subscription.sentTimeToken
+}
@@ -0,0 +1,270 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// sync.go contains the blocking variants of the requests that return typed values.
+package pubnubMessaging
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// PublishResult is the typed response of PublishSync.
+// Timetoken is the timetoken of the published message.
+// MessageId is the client generated id the message was published with.
+type PublishResult struct {
+    Timetoken   int64
+    MessageId   string
+}
+
+// HistoryResult is the typed response of HistorySync.
+// Messages are the decrypted history messages.
+// StartTimetoken is the timetoken of the first message returned.
+// EndTimetoken is the timetoken of the last message returned.
+type HistoryResult struct {
+    Messages         []interface{}
+    StartTimetoken   int64
+    EndTimetoken     int64
+}
+
+// HereNowResult is the typed response of HereNowSync.
+// Occupancy is the number of users connected to the channel.
+// Uuids are the uuids of the users connected to the channel.
+type HereNowResult struct {
+    Occupancy   int      `json:"occupancy"`
+    Uuids       []string `json:"uuids"`
+}
+
+// ValidateChannel returns an error if the channel or one of the comma separated
+// channels is empty, nil otherwise.
+func ValidateChannel(channel string) error {
+    channelArray := strings.Split(channel, ",")
+    for i := 0; i < len(channelArray); i++ {
+        if (strings.TrimSpace(channelArray[i]) == "") {
+            return fmt.Errorf("Invalid Channel: %s", channel)
+        }
+    }
+    return nil
+}
+
+// PublishSync is the blocking variant of Publish.
+//
+// It accepts the following parameters:
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted.
+//
+// returns the PublishResult,
+// error if any.
+func (pub *Pubnub) PublishSync(channel string, message interface{}) (PublishResult, error) {
+    return pub.PublishSyncWithContext(context.Background(), channel, message)
+}
+
+// PublishSyncWithContext is the context aware variant of PublishSync.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted.
+//
+// returns the PublishResult,
+// error if any.
+func (pub *Pubnub) PublishSyncWithContext(ctx context.Context, channel string, message interface{}) (PublishResult, error) {
+    return pub.PublishSyncWithOptions(ctx, channel, message, PublishOptions{})
+}
+
+// PublishSyncWithOptions is the variant of PublishSyncWithContext that publishes the message with the options.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: The Pubnub channel to which the message is to be posted.
+// message: message to be posted.
+// options: the options of the publish request, e.g. the http method, the storage or the metadata.
+//
+// returns the PublishResult,
+// error if any.
+func (pub *Pubnub) PublishSyncWithOptions(ctx context.Context, channel string, message interface{}, options PublishOptions) (PublishResult, error) {
+    var result PublishResult
+    if(pub.PublishKey == ""){
+        return result, fmt.Errorf("Publish key required.")
+    }
+    if err := ValidateChannel(channel); err != nil {
+        return result, err
+    }
+    if(InvalidMessage(message)){
+        return result, fmt.Errorf("Invalid Message.")
+    }
+    query, err := options.QueryParameters()
+    if err != nil {
+        return result, err
+    }
+    jsonBytes, err := pub.SerializePublishMessage(message)
+    if err != nil {
+        return result, err
+    }
+    if err := pub.ValidateMessageSize(jsonBytes, options); err != nil {
+        return result, err
+    }
+    result.MessageId = CreateMessageId()
+    query.Set(_messageIdParameter, result.MessageId)
+    value, _, err := pub.RequestPublish(ctx, channel, pub.CreatePublishUrlWithQuery(channel, message, query), jsonBytes, options)
+    if err != nil {
+        return result, err
+    }
+    response, err := DecodeResponseArray(value)
+    if err != nil {
+        return result, err
+    }
+    if(len(response) > 2){
+        result.Timetoken = ParseTimetoken(response[2])
+    }
+    return result, nil
+}
+
+// HistorySync is the blocking variant of History.
+//
+// It accepts the following parameters:
+// channel: a single value of the pubnub channel.
+// limit: number of history messages to return.
+// start: start time from where to begin the history messages.
+// end: end time till where to get the history messages.
+// reverse: to fetch the messages in ascending order
+//
+// returns the HistoryResult,
+// error if any.
+func (pub *Pubnub) HistorySync(channel string, limit int, start int64, end int64, reverse bool) (HistoryResult, error) {
+    return pub.HistorySyncWithContext(context.Background(), channel, limit, start, end, reverse)
+}
+
+// HistorySyncWithContext is the context aware variant of HistorySync.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: a single value of the pubnub channel.
+// limit: number of history messages to return.
+// start: start time from where to begin the history messages.
+// end: end time till where to get the history messages.
+// reverse: to fetch the messages in ascending order
+//
+// returns the HistoryResult,
+// error if any.
+func (pub *Pubnub) HistorySyncWithContext(ctx context.Context, channel string, limit int, start int64, end int64, reverse bool) (HistoryResult, error) {
+    var result HistoryResult
+    if err := ValidateChannel(channel); err != nil {
+        return result, err
+    }
+    value, _, err := pub.RequestHistory(ctx, channel, limit, start, end, reverse)
+    if err != nil {
+        return result, err
+    }
+    data, _, _, _ := pub.parseJsonAndTrackDecryptErrors(value, OperationHistory, channel, nil)
+    if errUnmarshal := json.Unmarshal([]byte(data), &result.Messages); errUnmarshal != nil {
+        return result, &InvalidJsonError{Err: errUnmarshal}
+    }
+    response, err := DecodeResponseArray(value)
+    if err != nil {
+        return result, err
+    }
+    if(len(response) > 2){
+        result.StartTimetoken = ParseTimetoken(response[1])
+        result.EndTimetoken = ParseTimetoken(response[2])
+    }
+    return result, nil
+}
+
+// HereNowSync is the blocking variant of HereNow.
+//
+// It accepts the following parameters:
+// channel: a single value of the pubnub channel.
+//
+// returns the HereNowResult,
+// error if any.
+func (pub *Pubnub) HereNowSync(channel string) (HereNowResult, error) {
+    return pub.HereNowSyncWithContext(context.Background(), channel)
+}
+
+// HereNowSyncWithContext is the context aware variant of HereNowSync.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+// channel: a single value of the pubnub channel.
+//
+// returns the HereNowResult,
+// error if any.
+func (pub *Pubnub) HereNowSyncWithContext(ctx context.Context, channel string) (HereNowResult, error) {
+    var result HereNowResult
+    if err := ValidateChannel(channel); err != nil {
+        return result, err
+    }
+    value, _, err := pub.RequestHereNow(ctx, channel)
+    if err != nil {
+        return result, err
+    }
+    data, _, _, errJson := ParseJson(value, pub.CipherKey)
+    if errJson != nil {
+        return result, errJson
+    }
+    if errUnmarshal := json.Unmarshal([]byte(data), &result); errUnmarshal != nil {
+        return result, &InvalidJsonError{Err: errUnmarshal}
+    }
+    return result, nil
+}
+
+// Time is the blocking variant of GetTime.
+//
+// returns the server timetoken,
+// error if any.
+func (pub *Pubnub) Time() (int64, error) {
+    return pub.TimeWithContext(context.Background())
+}
+
+// TimeWithContext is the context aware variant of Time.
+//
+// It accepts the following parameters:
+// ctx: the context of the request.
+//
+// returns the server timetoken,
+// error if any.
+func (pub *Pubnub) TimeWithContext(ctx context.Context) (int64, error) {
+    value, _, err := pub.RequestTime(ctx)
+    if err != nil {
+        return 0, err
+    }
+    response, err := DecodeResponseArray(value)
+    if ((err != nil) || (len(response) == 0)) {
+        return 0, &InvalidJsonError{}
+    }
+    return ParseTimetoken(response[0]), nil
+}
+
+// DecodeResponseArray unmarshals a pubnub array response keeping the numbers as json.Number,
+// so that the 17 digit timetokens don't lose precision.
+//
+// It accepts the following parameters:
+// value: the response contents.
+//
+// returns the elements of the array,
+// error if any.
+func DecodeResponseArray(value []byte) ([]interface{}, error) {
+    var response []interface{}
+    decoder := json.NewDecoder(bytes.NewReader(value))
+    decoder.UseNumber()
+    if err := decoder.Decode(&response); err != nil {
+        return nil, &InvalidJsonError{Err: err}
+    }
+    return response, nil
+}
+
+// ParseTimetoken converts a timetoken decoded by DecodeResponseArray to int64.
+// Returns 0 if the value is not a timetoken.
+func ParseTimetoken(value interface{}) int64 {
+    var timetoken int64
+    switch v := value.(type) {
+        case json.Number:
+            timetoken, _ = v.Int64()
+        case string:
+            timetoken, _ = strconv.ParseInt(v, 10, 64)
+    }
+    return timetoken
+}
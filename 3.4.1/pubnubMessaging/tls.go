@@ -0,0 +1,104 @@
+// Package pubnubMessaging provides the implemetation to connect to pubnub api.
+// tls.go contains the TLS verification settings of the ssl connections.
+package pubnubMessaging
+
+import (
+    "crypto/sha256"
+    "crypto/tls"
+    "crypto/x509"
+    "encoding/base64"
+    "errors"
+    "strings"
+)
+
+// The string is returned as a message when the TLS handshake with the origin fails.
+const _tlsHandshakeFailed = "TLS handshake failed"
+
+// The string is returned as a message when none of the certificates of the origin matches the pinned keys.
+const _pinMismatch = "certificate pin mismatch"
+
+// PinMismatchError is returned by the verification of the pinned public keys when none of the
+// certificates of the origin matches the pins, it is the Err of the TlsError of the request.
+type PinMismatchError struct {
+}
+
+func (e *PinMismatchError) Error() string {
+    return _pinMismatch
+}
+
+// CreateTlsConfig creates the tls.Config of the built-in transports from the Config.
+// The certificate of the origin is verified against the RootCAs, or the system pool if RootCAs is nil,
+// unless InsecureSkipVerify is set. If PinnedPublicKeys are set one of the certificates of the 
+// origin must also match one of the pins.
+//
+// returns the pointer to the tls.Config.
+func (config Config) CreateTlsConfig() *tls.Config {
+    tlsConfig := &tls.Config{
+        RootCAs:               config.RootCAs,
+        Certificates:          config.Certificates,
+        InsecureSkipVerify:    config.InsecureSkipVerify,
+    }
+    if(len(config.PinnedPublicKeys) > 0){
+        tlsConfig.VerifyPeerCertificate = VerifyPinnedPublicKeys(config.PinnedPublicKeys)
+    }
+    return tlsConfig
+}
+
+// PublicKeyPin returns the pin of the certificate's public key, that is the 
+// base64 encoded SHA-256 hash of its DER encoded SubjectPublicKeyInfo.
+//
+// It accepts the following parameters:
+// certificate: the certificate to pin.
+//
+// returns the pin as string.
+func PublicKeyPin(certificate *x509.Certificate) string {
+    hash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+    return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// VerifyPinnedPublicKeys creates the func used as the tls.Config VerifyPeerCertificate.
+// The func returns a PinMismatchError if none of the certificates presented by the origin 
+// has a public key matching one of the pins.
+//
+// It accepts the following parameters:
+// pins: the base64 encoded SHA-256 hashes of the accepted SubjectPublicKeyInfo.
+//
+// returns the verification func.
+func VerifyPinnedPublicKeys(pins []string) func([][]byte, [][]*x509.Certificate) error {
+    return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+        for _, rawCert := range rawCerts {
+            certificate, err := x509.ParseCertificate(rawCert)
+            if(err != nil){
+                continue
+            }
+            pin := PublicKeyPin(certificate)
+            for _, u := range pins {
+                if(strings.TrimSpace(u) == pin){
+                    return nil
+                }
+            }
+        }
+        return &PinMismatchError{}
+    }
+}
+
+// IsTlsError checks if the error is caused by a failed TLS handshake or certificate verification:
+// a certificate of the origin that can't be verified or doesn't match the pins, a TLS alert
+// or a response that isn't TLS.
+//
+// It accepts the following parameters:
+// err: the error returned by the http request.
+//
+// returns true if it is a TLS error.
+func IsTlsError(err error) bool {
+    var unknownAuthorityError x509.UnknownAuthorityError
+    var hostnameError x509.HostnameError
+    var certificateInvalidError x509.CertificateInvalidError
+    var certificateVerificationError *tls.CertificateVerificationError
+    var recordHeaderError tls.RecordHeaderError
+    var alertError tls.AlertError
+    var pinMismatchError *PinMismatchError
+    return errors.As(err, &unknownAuthorityError) || errors.As(err, &hostnameError) ||
+        errors.As(err, &certificateInvalidError) || errors.As(err, &certificateVerificationError) ||
+        errors.As(err, &recordHeaderError) || errors.As(err, &alertError) || errors.As(err, &pinMismatchError)
+}
@@ -9,6 +9,8 @@ import(
     "strings"
     "time"
     "testing"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
 )
 
 // _timeoutMessage is the text message displayed when the 
@@ -233,3 +235,65 @@ func ParseResponseDummy(channel chan []byte){
         }
     }    
 }
+
+// InitWithMockOrigin initializes a pubnub instance that sends its requests to the 
+// local server instead of the pubnub origin. It is used by the tests that can run without 
+// access to the pubnub network.
+//
+// Parameters:
+// server: the local test server.
+// cipherKey: cipher key, can be empty.
+// uuid: custom uuid, can be empty.
+func InitWithMockOrigin(server *httptest.Server, cipherKey string, uuid string) *pubnubMessaging.Pubnub {
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = strings.TrimPrefix(server.URL, "http://")
+    return pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", cipherKey, uuid, config)
+}
+
+// InitWithConfig creates a Pubnub instance that sends its requests to the local server,
+// started or not, with the DefaultConfig changed by configure.
+//
+// Parameters:
+// server: the local test server.
+// cipherKey: cipher key, can be empty.
+// configure: changes the config of the instance, can be nil.
+func InitWithConfig(server *httptest.Server, cipherKey string, configure func(*pubnubMessaging.Config)) *pubnubMessaging.Pubnub {
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.Listener.Addr().String()
+    if(configure != nil){
+        configure(&config)
+    }
+    return pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", cipherKey, "", config)
+}
+
+// DrainResponse reads and discards all the responses on the channel 
+// till the channel is closed.
+func DrainResponse(channel chan []byte){
+    for {
+        _, ok := <-channel
+        if !ok {
+            break
+        }
+    }
+}
+
+// WaitForMessage reads the responses on the channel till a response containing 
+// the message is received or the timeout occurs.
+//
+// returns true if the message was received before the timeout.
+func WaitForMessage(channel chan []byte, message string, timeout time.Duration) bool{
+    timer := time.After(timeout)
+    for {
+        select {
+            case value, ok := <-channel:
+                if !ok {
+                    return false
+                }
+                if(strings.Contains(string(value), message)){
+                    return true
+                }
+            case <-timer:
+                return false
+        }
+    }
+}
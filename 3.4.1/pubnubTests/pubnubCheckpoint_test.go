@@ -0,0 +1,156 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubCheckpoint_test.go contains the tests related to the subscribe checkpoints
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestCheckpointStart prints a message on the screen to mark the beginning of
+// checkpoint tests.
+// PrintTestMessage is defined in the common.go file.
+func TestCheckpointStart(t *testing.T){
+    PrintTestMessage("==========Checkpoint tests start==========")
+}
+
+// WaitForCheckpoint returns the timetoken of the store once it is the expected one,
+// the last one loaded if it isn't within the timeout.
+func WaitForCheckpoint(store pubnubMessaging.CheckpointStore, expected string, timeout time.Duration) string {
+    deadline := time.Now().Add(timeout)
+    for {
+        timeToken, _ := store.Load()
+        if((timeToken == expected) || time.Now().After(deadline)){
+            return timeToken
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+}
+
+// TestFileCheckpointStore saves a timetoken to a FileCheckpointStore, another store of the same
+// file should load it and no temporary file should be left.
+func TestFileCheckpointStore(t *testing.T){
+    dir, _ := ioutil.TempDir("", "checkpoint")
+    defer os.RemoveAll(dir)
+    path := filepath.Join(dir, "checkpoint")
+
+    empty, err1 := pubnubMessaging.NewFileCheckpointStore(path).Load()
+    err2 := pubnubMessaging.NewFileCheckpointStore(path).Save("13796254500000001")
+    timeToken, err3 := pubnubMessaging.NewFileCheckpointStore(path).Load()
+    _, errTemporary := os.Stat(path + ".tmp")
+    if((err1 == nil) && (err2 == nil) && (err3 == nil) && (empty == "") && (timeToken == "13796254500000001") &&
+        os.IsNotExist(errTemporary)){
+        fmt.Println("Test 'FileCheckpointStore': passed.")
+    } else {
+        t.Error("Test 'FileCheckpointStore': failed.", empty, timeToken, err1, err2, err3, errTemporary)
+    }
+}
+
+// TestCheckpointSavedAfterMessages subscribes with a CheckpointStore, the timetoken of the
+// response with the messages should be saved once they are delivered.
+func TestCheckpointSavedAfterMessages(t *testing.T){
+    server := NewSubscribeServer("[[\"hello\"],\"13796254500000001\"]")
+    defer server.Close()
+    store := pubnubMessaging.NewMemoryCheckpointStore()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.CheckpointStore = store
+    })
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    if(!WaitForMessage(returnChannel, "hello", 5 * time.Second)){
+        t.Fatal("Test 'CheckpointSavedAfterMessages': failed. No message.")
+    }
+    timeToken := WaitForCheckpoint(store, "13796254500000001", 5 * time.Second)
+    if(timeToken == "13796254500000001"){
+        fmt.Println("Test 'CheckpointSavedAfterMessages': passed.")
+    } else {
+        t.Error("Test 'CheckpointSavedAfterMessages': failed.", timeToken)
+    }
+}
+
+// TestCheckpointResume subscribes with a CheckpointStore holding a timetoken, the first subscribe
+// request should be sent with it instead of 0 so that the messages published since are received.
+func TestCheckpointResume(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        if(strings.HasSuffix(r.URL.Path, "/0/0")){
+            fmt.Fprint(w, "[[],\"13796254500000009\"]")
+        } else if(strings.HasSuffix(r.URL.Path, "/13796254500000000")){
+            fmt.Fprint(w, "[[\"missed\"],\"13796254500000001\"]")
+        } else {
+            <-r.Context().Done()
+        }
+    }))
+    defer server.Close()
+    store := pubnubMessaging.NewMemoryCheckpointStore()
+    store.Save("13796254500000000")
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.CheckpointStore = store
+    })
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    if(WaitForMessage(returnChannel, "missed", 5 * time.Second)){
+        fmt.Println("Test 'CheckpointResume': passed.")
+    } else {
+        t.Error("Test 'CheckpointResume': failed.", pubnubInstance.SentTimeToken())
+    }
+}
+
+// TestCheckpointResumeConnected subscribes with a CheckpointStore holding a timetoken, the first
+// subscribe request is sent with it and its response has messages. The Connected status should
+// be sent on the status channel once, before the messages.
+func TestCheckpointResumeConnected(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        if(strings.HasSuffix(r.URL.Path, "/13796254500000000")){
+            fmt.Fprint(w, "[[\"missed\"],\"13796254500000001\"]")
+        } else if(strings.HasSuffix(r.URL.Path, "/13796254500000001")){
+            fmt.Fprint(w, "[[],\"13796254500000002\"]")
+        } else {
+            <-r.Context().Done()
+        }
+    }))
+    defer server.Close()
+    store := pubnubMessaging.NewMemoryCheckpointStore()
+    store.Save("13796254500000000")
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.CheckpointStore = store
+    })
+    defer pubnubInstance.Abort()
+    statusChannel := make(chan pubnubMessaging.StatusEvent, 10)
+    pubnubInstance.SetStatusChannel(statusChannel)
+
+    returnChannel := make(chan []byte, 10)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    _, connected := WaitForStatus(statusChannel, pubnubMessaging.StatusConnected, 5 * time.Second)
+    received := WaitForMessage(returnChannel, "missed", 5 * time.Second)
+    _, connectedTwice := WaitForStatus(statusChannel, pubnubMessaging.StatusConnected, 500 * time.Millisecond)
+    if(connected && received && !connectedTwice){
+        fmt.Println("Test 'CheckpointResumeConnected': passed.")
+    } else {
+        t.Error("Test 'CheckpointResumeConnected': failed.", connected, received, connectedTwice)
+    }
+}
+
+// TestCheckpointEnd prints a message on the screen to mark the end of
+// checkpoint tests.
+// PrintTestMessage is defined in the common.go file.
+func TestCheckpointEnd(t *testing.T){
+    PrintTestMessage("==========Checkpoint tests end==========")
+}
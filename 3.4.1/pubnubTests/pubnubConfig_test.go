@@ -0,0 +1,109 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubConfig_test.go contains the tests related to the per instance configuration
+package pubnubTests
+
+import (
+    "testing"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+    "fmt"
+)
+
+// TestConfigStart prints a message on the screen to mark the beginning of 
+// config tests.
+// PrintTestMessage is defined in the common.go file.
+func TestConfigStart(t *testing.T){
+    PrintTestMessage("==========Config tests start==========")
+}
+
+// TestConfigPerInstance initializes two instances with different configs and
+// checks that each instance keeps its own settings.
+func TestConfigPerInstance(t *testing.T){
+    config1 := pubnubMessaging.DefaultConfig()
+    config1.Origin = "origin1.pubnub.com"
+    config1.SubscribeTimeout = 20
+    config1.ResumeOnReconnect = false
+    config1.ProxyServer = "proxy1"
+    
+    config2 := pubnubMessaging.DefaultConfig()
+    config2.Origin = "origin2.pubnub.com"
+    config2.Ssl = true
+    config2.SubscribeTimeout = 30
+    config2.ResumeOnReconnect = true
+    
+    pubnubInstance1 := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config1)
+    pubnubInstance2 := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config2)
+    config1.Origin = "changed.pubnub.com"
+    
+    if(pubnubInstance1.Origin != "http://origin1.pubnub.com"){
+        t.Error("Test 'ConfigPerInstance': failed. Origin: " + pubnubInstance1.Origin)
+    } else if(pubnubInstance2.Origin != "https://origin2.pubnub.com"){
+        t.Error("Test 'ConfigPerInstance': failed. Origin: " + pubnubInstance2.Origin)
+    } else if(pubnubInstance1.Config().SubscribeTimeout != 20 || pubnubInstance2.Config().SubscribeTimeout != 30){
+        t.Error("Test 'ConfigPerInstance': failed. Subscribe timeouts are shared.")
+    } else if(pubnubInstance1.Config().ResumeOnReconnect || !pubnubInstance2.Config().ResumeOnReconnect){
+        t.Error("Test 'ConfigPerInstance': failed. Resume on reconnect is shared.")
+    } else if(!pubnubInstance1.Config().ProxyEnabled() || pubnubInstance2.Config().ProxyEnabled()){
+        t.Error("Test 'ConfigPerInstance': failed. Proxy is shared.")
+    } else {
+        fmt.Println("Test 'ConfigPerInstance': passed.")
+    }
+}
+
+// TestConfigDefaults checks that the zero valued settings are replaced by the defaults.
+func TestConfigDefaults(t *testing.T){
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", pubnubMessaging.Config{})
+    config := pubnubInstance.Config()
+    if((config.Origin == "") || (config.SubscribeTimeout <= 0) || (config.NonSubscribeTimeout <= 0) || (config.MaxRetries <= 0) || (config.RetryInterval <= 0) ||
+        (config.MaxIdleConnsPerHost <= 0) || (config.IdleConnTimeout <= 0) || (config.CheckpointInterval <= 0)){
+        t.Error("Test 'ConfigDefaults': failed.")
+    } else {
+        fmt.Println("Test 'ConfigDefaults': passed.")
+    }
+}
+
+// TestConfigUnlimitedRetries creates an instance with the UnlimitedRetries MaxRetries,
+// it should not be replaced by the default.
+func TestConfigUnlimitedRetries(t *testing.T){
+    config := pubnubMessaging.DefaultConfig()
+    config.MaxRetries = pubnubMessaging.UnlimitedRetries
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    if(pubnubInstance.Config().MaxRetries == pubnubMessaging.UnlimitedRetries){
+        fmt.Println("Test 'ConfigUnlimitedRetries': passed.")
+    } else {
+        t.Error("Test 'ConfigUnlimitedRetries': failed.", pubnubInstance.Config().MaxRetries)
+    }
+}
+
+// TestConfigCopy changes the maps and slices of the config after the instance is created and
+// of the config returned by the instance, the config of the instance should not change.
+func TestConfigCopy(t *testing.T){
+    config := pubnubMessaging.DefaultConfig()
+    config.RetryableErrors = map[string][]string{
+        pubnubMessaging.OperationTime: []string{pubnubMessaging.ErrorCategoryTimeout},
+    }
+    config.PinnedPublicKeys = []string{"pin1"}
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    config.RetryableErrors[pubnubMessaging.OperationTime][0] = pubnubMessaging.ErrorCategoryInvalidJson
+    config.RetryableErrors[pubnubMessaging.OperationHistory] = []string{pubnubMessaging.ErrorCategoryTimeout}
+    config.PinnedPublicKeys[0] = "pin2"
+
+    instanceConfig := pubnubInstance.Config()
+    instanceConfig.RetryableErrors[pubnubMessaging.OperationTime] = nil
+    instanceConfig.PinnedPublicKeys[0] = "pin3"
+
+    instanceConfig = pubnubInstance.Config()
+    categories := instanceConfig.RetryableErrors[pubnubMessaging.OperationTime]
+    if((len(instanceConfig.RetryableErrors) == 1) && (len(categories) == 1) &&
+        (categories[0] == pubnubMessaging.ErrorCategoryTimeout) && (instanceConfig.PinnedPublicKeys[0] == "pin1")){
+        fmt.Println("Test 'ConfigCopy': passed.")
+    } else {
+        t.Error("Test 'ConfigCopy': failed.", instanceConfig.RetryableErrors, instanceConfig.PinnedPublicKeys)
+    }
+}
+
+// TestConfigEnd prints a message on the screen to mark the end of 
+// config tests.
+// PrintTestMessage is defined in the common.go file.
+func TestConfigEnd(t *testing.T){
+    PrintTestMessage("==========Config tests end==========")
+}
@@ -0,0 +1,230 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubContext_test.go contains the tests related to the context aware requests
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "strings"
+    "time"
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestContextStart prints a message on the screen to mark the beginning of 
+// context tests.
+// PrintTestMessage is defined in the common.go file.
+func TestContextStart(t *testing.T){
+    PrintTestMessage("==========Context tests start==========")
+}
+
+// NewHangingServer starts a local server that never answers the publish requests
+// till the client goes away. 
+func NewHangingServer() *httptest.Server {
+    return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        <-r.Context().Done()
+    }))
+}
+
+// TestPublishWithContextDeadline publishes to a server that doesn't respond, 
+// the context deadline should abort the request with an "Operation Timeout".
+func TestPublishWithContextDeadline(t *testing.T){
+    server := NewHangingServer()
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    
+    ctx, cancel := context.WithTimeout(context.Background(), 500 * time.Millisecond)
+    defer cancel()
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go pubnubInstance.PublishWithContext(ctx, "testChannel", "message", returnChannel, errorChannel)
+    if(WaitForMessage(errorChannel, "Operation Timeout", 3 * time.Second)){
+        fmt.Println("Test 'PublishWithContextDeadline': passed.")
+    } else {
+        t.Error("Test 'PublishWithContextDeadline': failed.")
+    }
+}
+
+// TestHistoryWithContextCancel cancels the context of a history request 
+// to a server that doesn't respond, the request should be aborted.
+func TestHistoryWithContextCancel(t *testing.T){
+    server := NewHangingServer()
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    
+    ctx, cancel := context.WithCancel(context.Background())
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go pubnubInstance.HistoryWithContext(ctx, "testChannel", 10, 0, 0, false, returnChannel, errorChannel)
+    time.Sleep(200 * time.Millisecond)
+    cancel()
+    if(WaitForMessage(errorChannel, "Connection aborted", 3 * time.Second)){
+        fmt.Println("Test 'HistoryWithContextCancel': passed.")
+    } else {
+        t.Error("Test 'HistoryWithContextCancel': failed.")
+    }
+}
+
+// TestSubscribeWithContextCancel subscribes with a context and cancels it. 
+// The long-poll should be aborted and a leave request sent, the subscribe loop stops.
+func TestSubscribeWithContextCancel(t *testing.T){
+    pending := make(chan bool, 10)
+    leave := make(chan string, 10)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        if(strings.HasPrefix(r.URL.Path, "/subscribe/")){
+            if(strings.HasSuffix(r.URL.Path, "/0/0")){
+                fmt.Fprint(w, "[[],\"13796254500000000\"]")
+                return
+            }
+            pending <- true
+            <-r.Context().Done()
+            return
+        }
+        if(strings.HasSuffix(r.URL.Path, "/leave")){
+            leave <- r.URL.Path
+        }
+        fmt.Fprint(w, "{\"status\": 200, \"action\": \"leave\"}")
+    }))
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    
+    ctx, cancel := context.WithCancel(context.Background())
+    returnSubscribeChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.SubscribeWithContext(ctx, "testChannel", "", returnSubscribeChannel, false, errorChannel)
+    if(!WaitForMessage(returnSubscribeChannel, "connected", 5 * time.Second)){
+        t.Fatal("Test 'SubscribeWithContextCancel': failed. Not connected.")
+    }
+    <-pending
+    cancel()
+    select {
+        case path := <-leave:
+            if(!strings.Contains(path, "/channel/testChannel/")){
+                t.Error("Test 'SubscribeWithContextCancel': failed. Leave: " + path)
+            } else if(len(pubnubInstance.SubscribedChannels()) != 0){
+                t.Error("Test 'SubscribeWithContextCancel': failed. Still subscribed: ", pubnubInstance.SubscribedChannels())
+            } else {
+                fmt.Println("Test 'SubscribeWithContextCancel': passed.")
+            }
+        case <-time.After(5 * time.Second):
+            t.Error("Test 'SubscribeWithContextCancel': failed. No leave request.")
+    }
+    select {
+        case <-pending:
+            t.Error("Test 'SubscribeWithContextCancel': failed. Loop still running.")
+        case <-time.After(500 * time.Millisecond):
+    }
+}
+
+// TestSubscribeWithContextCancelDuringReconnect subscribes with a context to a server that responds
+// with 500 and a RetryPolicy delay of an hour, then cancels the context while the loop waits to
+// reconnect. The loop should stop without waiting, so that a new subscription receives its messages.
+func TestSubscribeWithContextCancelDuringReconnect(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        if(strings.HasPrefix(r.URL.Path, "/subscribe/demo/ch1/")){
+            w.WriteHeader(http.StatusInternalServerError)
+            fmt.Fprint(w, "[0,\"Internal Server Error\"]")
+        } else if(strings.HasPrefix(r.URL.Path, "/subscribe/demo/ch2/0/0")){
+            fmt.Fprint(w, "[[\"hello\"],\"13796254500000001\"]")
+        } else if(strings.HasPrefix(r.URL.Path, "/subscribe/")){
+            <-r.Context().Done()
+        } else {
+            fmt.Fprint(w, "{\"status\": 200, \"action\": \"leave\"}")
+        }
+    }))
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RetryPolicy = pubnubMessaging.NewLinearRetryPolicy(time.Hour, 3)
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    defer pubnubInstance.Abort()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    errorChannel := make(chan []byte)
+    go pubnubInstance.SubscribeWithContext(ctx, "ch1", "", make(chan []byte, 10), false, errorChannel)
+    if(WaitForError(errorChannel, 5 * time.Second) == nil){
+        t.Fatal("Test 'SubscribeWithContextCancelDuringReconnect': failed. No error.")
+    }
+    cancel()
+    for deadline := time.Now().Add(5 * time.Second); (len(pubnubInstance.SubscribedChannels()) != 0) && time.Now().Before(deadline); {
+        time.Sleep(10 * time.Millisecond)
+    }
+
+    returnChannel := make(chan []byte, 10)
+    errorChannel2 := make(chan []byte)
+    go DrainResponse(errorChannel2)
+    go pubnubInstance.Subscribe("ch2", "", returnChannel, false, errorChannel2)
+    if(WaitForMessage(returnChannel, "hello", 5 * time.Second)){
+        fmt.Println("Test 'SubscribeWithContextCancelDuringReconnect': passed.")
+    } else {
+        t.Error("Test 'SubscribeWithContextCancelDuringReconnect': failed.")
+    }
+}
+
+// subscribeContextKey is the key of the context value of TestSubscribeContextValues.
+type subscribeContextKey struct{}
+
+// TestSubscribeContextValues subscribes with a context holding a value, the subscribe requests
+// should be sent with the values of the context.
+func TestSubscribeContextValues(t *testing.T){
+    server := NewSubscribeServer("[[\"hello\"],\"13796254500000001\"]")
+    defer server.Close()
+    values := make(chan interface{}, 10)
+    pubnubInstance := InitWithConfig(server, "", WithInterceptors(
+        func(ctx context.Context, request *pubnubMessaging.PubnubRequest, next pubnubMessaging.RequestHandler) ([]byte, int, error) {
+            if(request.IsSubscribe){
+                select {
+                    case values <- ctx.Value(subscribeContextKey{}):
+                    default:
+                }
+            }
+            return next(ctx, request)
+        }))
+    defer pubnubInstance.Abort()
+
+    ctx := context.WithValue(context.Background(), subscribeContextKey{}, "trace")
+    returnChannel := make(chan []byte, 10)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.SubscribeWithContext(ctx, "ch1", "", returnChannel, false, errorChannel)
+    received := WaitForMessage(returnChannel, "hello", 5 * time.Second)
+    var value interface{}
+    select {
+        case value = <-values:
+        case <-time.After(5 * time.Second):
+    }
+    if(received && (value == "trace")){
+        fmt.Println("Test 'SubscribeContextValues': passed.")
+    } else {
+        t.Error("Test 'SubscribeContextValues': failed.", received, value)
+    }
+}
+
+// TestExecuteTimeWithoutContext calls ExecuteTime with its former signature, the request
+// should be sent without a context and the response sent to the channel.
+func TestExecuteTimeWithoutContext(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[13889582939380876]")
+    }))
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    returnChannel := make(chan []byte, 1)
+    errorChannel := make(chan []byte, 1)
+    go pubnubInstance.ExecuteTime(returnChannel, errorChannel, 0)
+    if(WaitForMessage(returnChannel, "13889582939380876", 3 * time.Second)){
+        fmt.Println("Test 'ExecuteTimeWithoutContext': passed.")
+    } else {
+        t.Error("Test 'ExecuteTimeWithoutContext': failed.")
+    }
+}
+
+// TestContextEnd prints a message on the screen to mark the end of 
+// context tests.
+// PrintTestMessage is defined in the common.go file.
+func TestContextEnd(t *testing.T){
+    PrintTestMessage("==========Context tests end==========")
+}
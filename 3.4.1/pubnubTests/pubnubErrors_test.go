@@ -0,0 +1,200 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubErrors_test.go contains the tests related to the typed errors
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "time"
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestErrorsStart prints a message on the screen to mark the beginning of
+// error tests.
+// PrintTestMessage is defined in the common.go file.
+func TestErrorsStart(t *testing.T){
+    PrintTestMessage("==========Error tests start==========")
+}
+
+// WaitForError decodes the next structured error received on the channel,
+// nil if none is received within the timeout.
+func WaitForError(channel chan []byte, timeout time.Duration) *pubnubMessaging.PubnubError {
+    select {
+        case value := <-channel:
+            pubnubError, err := pubnubMessaging.DecodeErrorResponse(value)
+            if err != nil {
+                return nil
+            }
+            return pubnubError
+        case <-time.After(timeout):
+            return nil
+    }
+}
+
+// TestAccessDeniedError publishes to a server that responds with 403,
+// the error channel should receive an AccessDeniedError with the operation, channel and status code.
+func TestAccessDeniedError(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        w.WriteHeader(http.StatusForbidden)
+        fmt.Fprint(w, "{\"status\": 403, \"message\": \"Forbidden\", \"error\": true}")
+    }))
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go pubnubInstance.Publish("testChannel", "message", returnChannel, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    if(pubnubError == nil){
+        t.Fatal("Test 'AccessDeniedError': failed. No structured error.")
+    }
+    accessDenied, ok := pubnubError.Err.(*pubnubMessaging.AccessDeniedError)
+    if(ok && (accessDenied.Message == "Forbidden") && (pubnubError.Operation == pubnubMessaging.OperationPublish) &&
+        (pubnubError.Channel == "testChannel") && (pubnubError.StatusCode == 403)){
+        fmt.Println("Test 'AccessDeniedError': passed.")
+    } else {
+        t.Error("Test 'AccessDeniedError': failed.", pubnubError)
+    }
+    if _, err := pubnubInstance.PublishSync("testChannel", "message"); err != nil {
+        if _, ok := err.(*pubnubMessaging.AccessDeniedError); !ok {
+            t.Error("Test 'AccessDeniedError': failed. PublishSync error: ", err)
+        }
+    } else {
+        t.Error("Test 'AccessDeniedError': failed. PublishSync succeeded.")
+    }
+}
+
+// TestInvalidJsonError requests the history from a server that responds with invalid json,
+// the error channel should receive an InvalidJsonError.
+func TestInvalidJsonError(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[1,")
+    }))
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 1)
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go pubnubInstance.History("testChannel", 10, 0, 0, false, returnChannel, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    go DrainResponse(errorChannel)
+    if(pubnubError == nil){
+        t.Fatal("Test 'InvalidJsonError': failed. No structured error.")
+    }
+    if _, ok := pubnubError.Err.(*pubnubMessaging.InvalidJsonError); ok && (pubnubError.Operation == pubnubMessaging.OperationHistory) {
+        fmt.Println("Test 'InvalidJsonError': passed.")
+    } else {
+        t.Error("Test 'InvalidJsonError': failed.", pubnubError)
+    }
+}
+
+// TestTimeoutError publishes to a server that doesn't respond with a context deadline,
+// the error channel should receive a TimeoutError.
+func TestTimeoutError(t *testing.T){
+    server := NewHangingServer()
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    ctx, cancel := context.WithTimeout(context.Background(), 300 * time.Millisecond)
+    defer cancel()
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go pubnubInstance.PublishWithContext(ctx, "testChannel", "message", returnChannel, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    if(pubnubError == nil){
+        t.Fatal("Test 'TimeoutError': failed. No structured error.")
+    }
+    if _, ok := pubnubError.Err.(*pubnubMessaging.TimeoutError); ok {
+        fmt.Println("Test 'TimeoutError': passed.")
+    } else {
+        t.Error("Test 'TimeoutError': failed.", pubnubError)
+    }
+}
+
+// TestNetworkUnavailableError sends a request to a closed server,
+// the error should be a NetworkUnavailableError.
+func TestNetworkUnavailableError(t *testing.T){
+    server := httptest.NewServer(http.NotFoundHandler())
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    server.Close()
+
+    _, err := pubnubInstance.Time()
+    if _, ok := err.(*pubnubMessaging.NetworkUnavailableError); ok {
+        fmt.Println("Test 'NetworkUnavailableError': passed.")
+    } else {
+        t.Error("Test 'NetworkUnavailableError': failed.", err)
+    }
+}
+
+// TestDecryptError decrypts a message that is not encrypted with the cipher key,
+// the error should be a DecryptError.
+func TestDecryptError(t *testing.T){
+    _, err := pubnubMessaging.DecryptString("enigma", "not encrypted")
+    if _, ok := err.(*pubnubMessaging.DecryptError); ok {
+        fmt.Println("Test 'DecryptError': passed.")
+    } else {
+        t.Error("Test 'DecryptError': failed.", err)
+    }
+}
+
+// TestHistoryDecryptError requests the history of a message that is not encrypted with the
+// cipher key, the error channel should receive a DecryptError with the operation and the channel.
+func TestHistoryDecryptError(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[[\"not encrypted\"],13796254500000001,13796254500000002]")
+    }))
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "enigma", "")
+
+    returnChannel := make(chan []byte, 1)
+    errorChannel := make(chan []byte, 1)
+    go pubnubInstance.History("testChannel", 10, 0, 0, false, returnChannel, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    if(pubnubError == nil){
+        t.Fatal("Test 'HistoryDecryptError': failed. No structured error.")
+    }
+    if _, ok := pubnubError.Err.(*pubnubMessaging.DecryptError); ok && (pubnubError.Operation == pubnubMessaging.OperationHistory) &&
+        (pubnubError.Channel == "testChannel") && WaitForMessage(returnChannel, "not encrypted", 5 * time.Second) {
+        fmt.Println("Test 'HistoryDecryptError': passed.")
+    } else {
+        t.Error("Test 'HistoryDecryptError': failed.", pubnubError)
+    }
+}
+
+// TestSubscribeDecryptError subscribes to two channels and receives a message of each, only the
+// message of the second channel is not encrypted with the cipher key. The error channel should
+// receive a DecryptError with the channel of that message.
+func TestSubscribeDecryptError(t *testing.T){
+    encrypted := pubnubMessaging.EncryptString("enigma", "\"hello\"")
+    server := NewSubscribeServer("[[\"" + encrypted + "\",\"not encrypted\"],\"13796254500000001\",\"ch1,ch2\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "enigma", "")
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte, 10)
+    errorChannel := make(chan []byte, 10)
+    go pubnubInstance.Subscribe("ch1,ch2", "", returnChannel, false, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    if(pubnubError == nil){
+        t.Fatal("Test 'SubscribeDecryptError': failed. No structured error.")
+    }
+    if _, ok := pubnubError.Err.(*pubnubMessaging.DecryptError); ok && (pubnubError.Operation == pubnubMessaging.OperationSubscribe) &&
+        (pubnubError.Channel == "ch2") {
+        fmt.Println("Test 'SubscribeDecryptError': passed.")
+    } else {
+        t.Error("Test 'SubscribeDecryptError': failed.", pubnubError)
+    }
+}
+
+// TestErrorsEnd prints a message on the screen to mark the end of
+// error tests.
+// PrintTestMessage is defined in the common.go file.
+func TestErrorsEnd(t *testing.T){
+    PrintTestMessage("==========Error tests end==========")
+}
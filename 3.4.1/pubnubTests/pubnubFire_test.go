@@ -0,0 +1,103 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubFire_test.go contains the tests related to the fire requests
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestFireStart prints a message on the screen to mark the beginning of
+// fire tests.
+// PrintTestMessage is defined in the common.go file.
+func TestFireStart(t *testing.T){
+    PrintTestMessage("==========Fire tests start==========")
+}
+
+// TestFire fires a message, it should be published with the storage and the replication
+// disabled and the response should be sent on the callback channel.
+func TestFire(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Fire("testChannel", "message", false, returnChannel, errorChannel)
+    if(!WaitForMessage(returnChannel, "13796254500000001", 5 * time.Second)){
+        t.Fatal("Test 'Fire': failed. No response.")
+    }
+    sent := requests()
+    if((len(sent) == 1) && (sent[0].Query["store"][0] == "0") && (sent[0].Query["norep"][0] == "true")){
+        fmt.Println("Test 'Fire': passed.")
+    } else {
+        t.Error("Test 'Fire': failed.", sent)
+    }
+}
+
+// TestFireSkipResponseBody fires a message to a server that holds the response body after the
+// headers, the callback channel should receive [1,"Sent"] without waiting for the body.
+func TestFireSkipResponseBody(t *testing.T){
+    release := make(chan struct{})
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        w.WriteHeader(http.StatusOK)
+        w.(http.Flusher).Flush()
+        <-release
+        fmt.Fprint(w, "[1,\"Sent\",\"13796254500000001\"]")
+    }))
+    defer server.Close()
+    defer close(release)
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Fire("testChannel", "message", true, returnChannel, errorChannel)
+    select {
+        case value := <-returnChannel:
+            if(string(value) == "[1,\"Sent\"]"){
+                fmt.Println("Test 'FireSkipResponseBody': passed.")
+            } else {
+                t.Error("Test 'FireSkipResponseBody': failed.", string(value))
+            }
+        case <-time.After(3 * time.Second):
+            t.Error("Test 'FireSkipResponseBody': failed. The response body was awaited.")
+    }
+}
+
+// TestFireNetworkError fires a message to a closed server without waiting for the response body,
+// the error channel should still receive the NetworkUnavailableError.
+func TestFireNetworkError(t *testing.T){
+    server := httptest.NewServer(http.NotFoundHandler())
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 1)
+    server.Close()
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go pubnubInstance.Fire("testChannel", "message", true, returnChannel, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    if(pubnubError == nil){
+        t.Fatal("Test 'FireNetworkError': failed. No structured error.")
+    }
+    if _, ok := pubnubError.Err.(*pubnubMessaging.NetworkUnavailableError); ok && (pubnubError.Operation == pubnubMessaging.OperationPublish) {
+        fmt.Println("Test 'FireNetworkError': passed.")
+    } else {
+        t.Error("Test 'FireNetworkError': failed.", pubnubError)
+    }
+}
+
+// TestFireEnd prints a message on the screen to mark the end of
+// fire tests.
+// PrintTestMessage is defined in the common.go file.
+func TestFireEnd(t *testing.T){
+    PrintTestMessage("==========Fire tests end==========")
+}
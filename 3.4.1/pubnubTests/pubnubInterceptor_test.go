@@ -0,0 +1,156 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubInterceptor_test.go contains the tests related to the request interceptors
+package pubnubTests
+
+import (
+    "testing"
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestInterceptorStart prints a message on the screen to mark the beginning of
+// interceptor tests.
+// PrintTestMessage is defined in the common.go file.
+func TestInterceptorStart(t *testing.T){
+    PrintTestMessage("==========Interceptor tests start==========")
+}
+
+// WithInterceptors returns the configure func of InitWithConfig that sets the interceptors
+// and a RequestRetryPolicy of 3 retries.
+func WithInterceptors(interceptors ...pubnubMessaging.Interceptor) func(*pubnubMessaging.Config) {
+    return func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 3)
+        config.Interceptors = interceptors
+    }
+}
+
+// TestInterceptorOrder requests the time through two interceptors, the first one should run
+// around the second one and the query parameter it adds should be sent.
+func TestInterceptorOrder(t *testing.T){
+    auth := ""
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        auth = r.URL.Query().Get("auth")
+        fmt.Fprint(w, "[13796254500000001]")
+    }))
+    defer server.Close()
+    var calls []string
+    pubnubInstance := InitWithConfig(server, "", WithInterceptors(
+        func(ctx context.Context, request *pubnubMessaging.PubnubRequest, next pubnubMessaging.RequestHandler) ([]byte, int, error) {
+            calls = append(calls, "first")
+            request.Query.Set("auth", "secret")
+            value, responseCode, err := next(ctx, request)
+            calls = append(calls, "first done")
+            return value, responseCode, err
+        },
+        func(ctx context.Context, request *pubnubMessaging.PubnubRequest, next pubnubMessaging.RequestHandler) ([]byte, int, error) {
+            calls = append(calls, "second " + request.Operation)
+            return next(ctx, request)
+        }))
+
+    _, err := pubnubInstance.Time()
+    if((err == nil) && (auth == "secret") && (strings.Join(calls, ",") == "first,second Time,first done")){
+        fmt.Println("Test 'InterceptorOrder': passed.")
+    } else {
+        t.Error("Test 'InterceptorOrder': failed.", err, auth, calls)
+    }
+}
+
+// TestInterceptorModifiesResponse requests the history from a server that responds with invalid json,
+// the interceptor replaces the response before it is parsed and should receive the structured request.
+func TestInterceptorModifiesResponse(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(invalidJson))
+    defer server.Close()
+    var received pubnubMessaging.PubnubRequest
+    pubnubInstance := InitWithConfig(server, "", WithInterceptors(
+        func(ctx context.Context, request *pubnubMessaging.PubnubRequest, next pubnubMessaging.RequestHandler) ([]byte, int, error) {
+            received = *request
+            _, responseCode, err := next(ctx, request)
+            return []byte("[[\"hello\"],13796254500000001,13796254500000002]"), responseCode, err
+        }))
+
+    result, err := pubnubInstance.HistorySync("testChannel", 10, 0, 0, false)
+    if((err == nil) && (len(result.Messages) == 1) && (received.Operation == pubnubMessaging.OperationHistory) &&
+        (received.Path == "/v2/history/sub-key/demo/channel/testChannel") && (received.Query.Get("count") == "10") &&
+        (len(received.Channels) == 1) && (received.Channels[0] == "testChannel")){
+        fmt.Println("Test 'InterceptorModifiesResponse': passed.")
+    } else {
+        t.Error("Test 'InterceptorModifiesResponse': failed.", err, result, received)
+    }
+}
+
+// TestInterceptorFaultInjection publishes through an interceptor that fails the first attempt
+// without sending it, the publish should be retried and succeed.
+func TestInterceptorFaultInjection(t *testing.T){
+    server, requests := NewFailingServer(0, nil, "[1,\"Sent\",\"13796254500000001\"]")
+    defer server.Close()
+    attempts := 0
+    pubnubInstance := InitWithConfig(server, "", WithInterceptors(
+        func(ctx context.Context, request *pubnubMessaging.PubnubRequest, next pubnubMessaging.RequestHandler) ([]byte, int, error) {
+            attempts++
+            if(attempts == 1){
+                return nil, 0, &pubnubMessaging.NetworkUnavailableError{Message: "injected",
+                    Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("injected")}}
+            }
+            return next(ctx, request)
+        }))
+
+    result, err := pubnubInstance.PublishSync("testChannel", "message")
+    sent := requests()
+    if((err == nil) && (attempts == 2) && (len(sent) == 1) && (sent[0].URL.Path == "/publish/demo/demo/0/testChannel/0/\"message\"") &&
+        (sent[0].URL.Query().Get("messageid") == result.MessageId)){
+        fmt.Println("Test 'InterceptorFaultInjection': passed.")
+    } else {
+        t.Error("Test 'InterceptorFaultInjection': failed.", err, attempts, len(sent))
+    }
+}
+
+// TestSubscribeAndLeaveIntercepted subscribes to and unsubscribes from a channel,
+// the subscribe and the leave requests should go through the interceptor.
+func TestSubscribeAndLeaveIntercepted(t *testing.T){
+    server := NewSubscribeServer("[[],\"13796254500000001\"]")
+    defer server.Close()
+    var lock sync.Mutex
+    operations := make(map[string][]string)
+    pubnubInstance := InitWithConfig(server, "", WithInterceptors(
+        func(ctx context.Context, request *pubnubMessaging.PubnubRequest, next pubnubMessaging.RequestHandler) ([]byte, int, error) {
+            lock.Lock()
+            operations[request.Operation] = request.Channels
+            lock.Unlock()
+            return next(ctx, request)
+        }))
+    defer pubnubInstance.Abort()
+    statusChannel := make(chan pubnubMessaging.StatusEvent, 10)
+    pubnubInstance.SetStatusChannel(statusChannel)
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    WaitForStatus(statusChannel, pubnubMessaging.StatusConnected, 5 * time.Second)
+    pubnubInstance.Unsubscribe("ch1", returnChannel, errorChannel)
+
+    lock.Lock()
+    defer lock.Unlock()
+    subscribe, leave := operations[pubnubMessaging.OperationSubscribe], operations[pubnubMessaging.OperationLeave]
+    if((len(subscribe) == 1) && (subscribe[0] == "ch1") && (len(leave) == 1) && (leave[0] == "ch1")){
+        fmt.Println("Test 'SubscribeAndLeaveIntercepted': passed.")
+    } else {
+        t.Error("Test 'SubscribeAndLeaveIntercepted': failed.", operations)
+    }
+}
+
+// TestInterceptorEnd prints a message on the screen to mark the end of
+// interceptor tests.
+// PrintTestMessage is defined in the common.go file.
+func TestInterceptorEnd(t *testing.T){
+    PrintTestMessage("==========Interceptor tests end==========")
+}
@@ -0,0 +1,144 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubListener_test.go contains the tests related to the listeners of the subscriptions
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "time"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestListenerStart prints a message on the screen to mark the beginning of
+// listener tests.
+// PrintTestMessage is defined in the common.go file.
+func TestListenerStart(t *testing.T){
+    PrintTestMessage("==========Listener tests start==========")
+}
+
+// TestListener is a Listener that forwards the messages and the status events to channels.
+type TestListener struct {
+    Messages   chan pubnubMessaging.Message
+    Statuses   chan pubnubMessaging.StatusEvent
+}
+
+// NewTestListener creates a TestListener with buffered channels.
+func NewTestListener() *TestListener {
+    return &TestListener{
+        Messages:   make(chan pubnubMessaging.Message, 10),
+        Statuses:   make(chan pubnubMessaging.StatusEvent, 10),
+    }
+}
+
+func (l *TestListener) OnMessage(message pubnubMessaging.Message) {
+    l.Messages <- message
+}
+
+func (l *TestListener) OnPresence(event pubnubMessaging.Message) {
+    l.Messages <- event
+}
+
+func (l *TestListener) OnStatus(event pubnubMessaging.StatusEvent) {
+    l.Statuses <- event
+}
+
+// TestMultipleListeners subscribes two listeners to the same channel,
+// both should receive the message.
+func TestMultipleListeners(t *testing.T){
+    server := NewSubscribeServer("[[\"hello\"],\"13796254500000001\",\"ch1\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    first := NewTestListener()
+    second := NewTestListener()
+    if err := pubnubInstance.SubscribeWithListener("ch1", "", first, false); err != nil {
+        t.Fatal("Test 'MultipleListeners': failed.", err)
+    }
+    if err := pubnubInstance.SubscribeWithListener("ch1", "", second, false); err != nil {
+        t.Fatal("Test 'MultipleListeners': failed.", err)
+    }
+    message1, ok1 := WaitForTypedMessage(first.Messages, 5 * time.Second)
+    message2, ok2 := WaitForTypedMessage(second.Messages, 5 * time.Second)
+    if(ok1 && ok2 && (message1.Data == "hello") && (message2.Data == "hello") && (message2.Channel == "ch1")){
+        fmt.Println("Test 'MultipleListeners': passed.")
+    } else {
+        t.Error("Test 'MultipleListeners': failed.", message1, message2)
+    }
+}
+
+// TestGlobalListener adds a listener with AddListener and subscribes with the chan based Subscribe,
+// the listener should receive the Connected status and the message, and the callback channel
+// the json response of the message.
+func TestGlobalListener(t *testing.T){
+    server := NewSubscribeServer("[[\"hello\"],\"13796254500000001\",\"ch1\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    listener := NewTestListener()
+    pubnubInstance.AddListener(listener)
+    returnChannel := make(chan []byte, 10)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+
+    event, ok := WaitForStatus(listener.Statuses, pubnubMessaging.StatusConnected, 5 * time.Second)
+    if(!ok || (len(event.Channels) != 1) || (event.Channels[0] != "ch1")){
+        t.Fatal("Test 'GlobalListener': failed. Connected: ", event)
+    }
+    message, ok := WaitForTypedMessage(listener.Messages, 5 * time.Second)
+    if(!ok || (message.Data != "hello")){
+        t.Fatal("Test 'GlobalListener': failed. Message: ", message)
+    }
+    if(WaitForMessage(returnChannel, "[[\"hello\"],\"13796254500000001\",\"ch1\"]", 5 * time.Second)){
+        fmt.Println("Test 'GlobalListener': passed.")
+    } else {
+        t.Error("Test 'GlobalListener': failed. Callback channel.")
+    }
+    pubnubInstance.RemoveListener(listener)
+}
+
+// TestChannelListenerStatus sends the status events to a ChannelListener,
+// the Connected response should be sent on the callback channel and the
+// Disconnected response on the error channel.
+func TestChannelListenerStatus(t *testing.T){
+    callbackChannel := make(chan []byte, 1)
+    errorChannel := make(chan []byte, 1)
+    listener := &pubnubMessaging.ChannelListener{
+        CallbackChannel:   callbackChannel,
+        ErrorChannel:      errorChannel,
+    }
+    listener.OnStatus(pubnubMessaging.CreateStatusEvent(pubnubMessaging.StatusConnected, "ch1", 0))
+    listener.OnStatus(pubnubMessaging.CreateStatusEvent(pubnubMessaging.StatusDisconnected, "ch1", 1))
+    connected := string(<-callbackChannel)
+    disconnected := string(<-errorChannel)
+    if((connected == pubnubMessaging.CreateStatusResponse(pubnubMessaging.StatusConnected, "ch1", 0)) &&
+        (disconnected == pubnubMessaging.CreateStatusResponse(pubnubMessaging.StatusDisconnected, "ch1", 1))){
+        fmt.Println("Test 'ChannelListenerStatus': passed.")
+    } else {
+        t.Error("Test 'ChannelListenerStatus': failed.", connected, disconnected)
+    }
+}
+
+// TestGetChannelForPubnubChannel adds a ChannelListener to a presence subscription, its error
+// channel should be returned for the channel suffixed with "-pnpres".
+func TestGetChannelForPubnubChannel(t *testing.T){
+    pubnubInstance := pubnubMessaging.PubnubInit("demo", "demo", "", "", false, "")
+    errorChannel := make(chan []byte)
+    pubnubInstance.AddChannelListener("ch1-pnpres", &pubnubMessaging.ChannelListener{ErrorChannel: errorChannel})
+    c, isPresence := pubnubInstance.GetChannelForPubnubChannel("ch1-pnpres", true)
+    notFound, _ := pubnubInstance.GetChannelForPubnubChannel("ch1", true)
+    if((c == errorChannel) && isPresence && (notFound == nil)){
+        fmt.Println("Test 'GetChannelForPubnubChannel': passed.")
+    } else {
+        t.Error("Test 'GetChannelForPubnubChannel': failed.", isPresence)
+    }
+}
+
+// TestListenerEnd prints a message on the screen to mark the end of
+// listener tests.
+// PrintTestMessage is defined in the common.go file.
+func TestListenerEnd(t *testing.T){
+    PrintTestMessage("==========Listener tests end==========")
+}
@@ -0,0 +1,163 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubLogger_test.go contains the tests related to the pluggable logger
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "bytes"
+    "encoding/json"
+    "strings"
+    "sync"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestLoggerStart prints a message on the screen to mark the beginning of
+// logger tests.
+// PrintTestMessage is defined in the common.go file.
+func TestLoggerStart(t *testing.T){
+    PrintTestMessage("==========Logger tests start==========")
+}
+
+// LogEntry is an entry recorded by the RecordingLogger.
+type LogEntry struct {
+    Level     pubnubMessaging.LogLevel
+    Message   string
+    Fields    pubnubMessaging.LogFields
+}
+
+// RecordingLogger is the Logger that records the entries for the tests.
+type RecordingLogger struct {
+    lock      sync.Mutex
+    entries   []LogEntry
+}
+
+// Log records the entry.
+func (logger *RecordingLogger) Log(level pubnubMessaging.LogLevel, message string, fields pubnubMessaging.LogFields) {
+    logger.lock.Lock()
+    defer logger.lock.Unlock()
+    logger.entries = append(logger.entries, LogEntry{Level: level, Message: message, Fields: fields})
+}
+
+// Find returns the first recorded entry with the message, nil if there is none.
+func (logger *RecordingLogger) Find(message string) *LogEntry {
+    logger.lock.Lock()
+    defer logger.lock.Unlock()
+    for i := range logger.entries {
+        if(logger.entries[i].Message == message){
+            entry := logger.entries[i]
+            return &entry
+        }
+    }
+    return nil
+}
+
+// TestJsonLogger writes entries of each level to a JsonLogger of the LogLevelInfo level,
+// the debug entry should be skipped and the others written as json lines with their fields.
+func TestJsonLogger(t *testing.T){
+    var buffer bytes.Buffer
+    logger := pubnubMessaging.NewJsonLogger(&buffer, pubnubMessaging.LogLevelInfo)
+    logger.Log(pubnubMessaging.LogLevelDebug, "skipped", pubnubMessaging.LogFields{})
+    logger.Log(pubnubMessaging.LogLevelWarn, "request failed", pubnubMessaging.LogFields{
+        Operation: pubnubMessaging.OperationPublish, Channel: "ch1", StatusCode: 500, Duration: 1500 * time.Microsecond})
+    lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+    var entry map[string]interface{}
+    err := json.Unmarshal([]byte(lines[0]), &entry)
+    if((len(lines) == 1) && (err == nil) && (entry["level"] == "warn") && (entry["message"] == "request failed") &&
+        (entry["operation"] == "Publish") && (entry["channel"] == "ch1") && (entry["statusCode"] == float64(500)) &&
+        (entry["durationMs"] == 1.5)){
+        fmt.Println("Test 'JsonLogger': passed.")
+    } else {
+        t.Error("Test 'JsonLogger': failed.", lines, err)
+    }
+}
+
+// TestRequestLogged requests the time, the start and the end of the request should be logged
+// with the operation, the status code and the duration.
+func TestRequestLogged(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[13796254500000001]")
+    }))
+    defer server.Close()
+    logger := &RecordingLogger{}
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.Logger = logger
+    })
+
+    _, err := pubnubInstance.Time()
+    started := logger.Find("request started")
+    finished := logger.Find("request finished")
+    if((err == nil) && (started != nil) && (finished != nil) && (started.Fields.Operation == pubnubMessaging.OperationTime) &&
+        (finished.Level == pubnubMessaging.LogLevelDebug) && (finished.Fields.StatusCode == 200) && (finished.Fields.Duration > 0)){
+        fmt.Println("Test 'RequestLogged': passed.")
+    } else {
+        t.Error("Test 'RequestLogged': failed.", err, started, finished)
+    }
+}
+
+// TestDecryptFailureLogged requests the history of a message that is not encrypted with the
+// cipher key, the decrypt failure should be logged with the operation and the channel.
+func TestDecryptFailureLogged(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[[\"not encrypted\"],13796254500000001,13796254500000002]")
+    }))
+    defer server.Close()
+    logger := &RecordingLogger{}
+    pubnubInstance := InitWithConfig(server, "enigma", func(config *pubnubMessaging.Config){
+        config.Logger = logger
+    })
+
+    _, err := pubnubInstance.HistorySync("testChannel", 10, 0, 0, false)
+    entry := logger.Find("decrypt failed")
+    if entry == nil {
+        t.Fatal("Test 'DecryptFailureLogged': failed. No entry.", err)
+    }
+    if _, ok := entry.Fields.Err.(*pubnubMessaging.DecryptError); ok && (entry.Level == pubnubMessaging.LogLevelWarn) &&
+        (entry.Fields.Operation == pubnubMessaging.OperationHistory) && (entry.Fields.Channel == "testChannel") {
+        fmt.Println("Test 'DecryptFailureLogged': passed.")
+    } else {
+        t.Error("Test 'DecryptFailureLogged': failed.", entry)
+    }
+}
+
+// TestReconnectLogged subscribes to a closed server with a RetryPolicy of 1 attempt,
+// the reconnect and the max retries should be logged.
+func TestReconnectLogged(t *testing.T){
+    server := httptest.NewServer(http.NotFoundHandler())
+    logger := &RecordingLogger{}
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 1)
+    config.Logger = logger
+    server.Close()
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    defer pubnubInstance.Abort()
+    statusChannel := make(chan pubnubMessaging.StatusEvent, 10)
+    pubnubInstance.SetStatusChannel(statusChannel)
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    WaitForStatus(statusChannel, pubnubMessaging.StatusMaxRetriesReached, 5 * time.Second)
+
+    reconnecting := logger.Find("reconnecting")
+    maxRetries := logger.Find("max retries reached")
+    if((reconnecting != nil) && (maxRetries != nil) && (reconnecting.Fields.Channel == "ch1") &&
+        (reconnecting.Fields.RetryCount == 1) && (maxRetries.Level == pubnubMessaging.LogLevelError)){
+        fmt.Println("Test 'ReconnectLogged': passed.")
+    } else {
+        t.Error("Test 'ReconnectLogged': failed.", reconnecting, maxRetries)
+    }
+}
+
+// TestLoggerEnd prints a message on the screen to mark the end of
+// logger tests.
+// PrintTestMessage is defined in the common.go file.
+func TestLoggerEnd(t *testing.T){
+    PrintTestMessage("==========Logger tests end==========")
+}
@@ -0,0 +1,136 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubMessageSize_test.go contains the tests related to the message size limit
+package pubnubTests
+
+import (
+    "testing"
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestMessageSizeStart prints a message on the screen to mark the beginning of
+// message size tests.
+// PrintTestMessage is defined in the common.go file.
+func TestMessageSizeStart(t *testing.T){
+    PrintTestMessage("==========Message size tests start==========")
+}
+
+// TestMessageSize checks that MessageSize counts the serialized message once escaped in the url,
+// and the encrypted message with a cipher key.
+func TestMessageSize(t *testing.T){
+    plain, err1 := pubnubMessaging.MessageSize("a b", "")
+    encrypted, err2 := pubnubMessaging.MessageSize("a b", "enigma")
+    jsonBytes, _ := pubnubMessaging.SerializeMessage("a b", "enigma")
+    // "a b" is escaped as %22a%20b%22
+    if((err1 == nil) && (err2 == nil) && (plain == 11) && (encrypted == pubnubMessaging.EncodedMessageSize(jsonBytes)) &&
+        (encrypted > len(jsonBytes))){
+        fmt.Println("Test 'MessageSize': passed.")
+    } else {
+        t.Error("Test 'MessageSize': failed.", plain, encrypted, err1, err2)
+    }
+}
+
+// TestMessageTooLarge publishes a message larger than the default limit of 32 KiB, by POST since it
+// is above the POST threshold, PublishSync should fail with a MessageTooLargeError of the length of
+// the json body without sending the request.
+func TestMessageTooLarge(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.PublishPostThreshold = 0
+    })
+
+    message := strings.Repeat("x", 32 * 1024)
+    jsonBytes, _ := pubnubMessaging.SerializeMessage(message, "")
+    _, err := pubnubInstance.PublishSync("testChannel", message)
+    var tooLarge *pubnubMessaging.MessageTooLargeError
+    if(errors.As(err, &tooLarge) && (tooLarge.Size == len(jsonBytes)) && (tooLarge.MaxSize == 32 * 1024) &&
+        (pubnubMessaging.ErrorCategory(err) == pubnubMessaging.ErrorCategoryMessageTooLarge) && (len(requests()) == 0)){
+        fmt.Println("Test 'MessageTooLarge': passed.")
+    } else {
+        t.Error("Test 'MessageTooLarge': failed.", err, requests())
+    }
+}
+
+// TestMessageSizeByMethod publishes a message whose url escaped size is above the limit but not its
+// json length, it should fail with a MessageTooLargeError by GET and be sent by POST.
+func TestMessageSizeByMethod(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.MaxMessageSize = 64
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+
+    message := strings.Repeat("é", 15)
+    size, _ := pubnubMessaging.MessageSize(message, "")
+    _, errGet := pubnubInstance.PublishSyncWithOptions(context.Background(), "testChannel", message,
+        pubnubMessaging.PublishOptions{Method: pubnubMessaging.PublishMethodGet})
+    _, errPost := pubnubInstance.PublishSyncWithOptions(context.Background(), "testChannel", message,
+        pubnubMessaging.PublishOptions{Method: pubnubMessaging.PublishMethodPost})
+    tooLarge, ok := errGet.(*pubnubMessaging.MessageTooLargeError)
+    sent := requests()
+    if(ok && (tooLarge.Size == size) && (size > 64) && (errPost == nil) && (len(sent) == 1) && (sent[0].Method == "POST")){
+        fmt.Println("Test 'MessageSizeByMethod': passed.")
+    } else {
+        t.Error("Test 'MessageSizeByMethod': failed.", errGet, errPost, sent)
+    }
+}
+
+// TestMessageTooLargeErrorChannel publishes an encrypted message larger than a configured limit,
+// the error channel should receive the MessageTooLargeError with the size of the encrypted message.
+func TestMessageTooLargeErrorChannel(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.MaxMessageSize = 64
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "enigma", "", config)
+
+    message := strings.Repeat("x", 40)
+    size, _ := pubnubMessaging.MessageSize(message, "enigma")
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go pubnubInstance.Publish("testChannel", message, returnChannel, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    if(pubnubError == nil){
+        t.Fatal("Test 'MessageTooLargeErrorChannel': failed. No structured error.")
+    }
+    tooLarge, ok := pubnubError.Err.(*pubnubMessaging.MessageTooLargeError)
+    if(ok && (tooLarge.Size == size) && (tooLarge.MaxSize == 64) && (pubnubError.Operation == pubnubMessaging.OperationPublish) &&
+        (len(requests()) == 0)){
+        fmt.Println("Test 'MessageTooLargeErrorChannel': passed.")
+    } else {
+        t.Error("Test 'MessageTooLargeErrorChannel': failed.", pubnubError, requests())
+    }
+}
+
+// TestMessageSizeUnlimited publishes a message larger than the default limit with a negative
+// MaxMessageSize, the message should be sent.
+func TestMessageSizeUnlimited(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.MaxMessageSize = -1
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+
+    _, err := pubnubInstance.PublishSync("testChannel", strings.Repeat("x", 40 * 1024))
+    if((err == nil) && (len(requests()) == 1)){
+        fmt.Println("Test 'MessageSizeUnlimited': passed.")
+    } else {
+        t.Error("Test 'MessageSizeUnlimited': failed.", err, requests())
+    }
+}
+
+// TestMessageSizeEnd prints a message on the screen to mark the end of
+// message size tests.
+// PrintTestMessage is defined in the common.go file.
+func TestMessageSizeEnd(t *testing.T){
+    PrintTestMessage("==========Message size tests end==========")
+}
@@ -0,0 +1,125 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubMessage_test.go contains the tests related to the typed Message envelope of the subscriptions
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "strings"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestMessageStart prints a message on the screen to mark the beginning of
+// message tests.
+// PrintTestMessage is defined in the common.go file.
+func TestMessageStart(t *testing.T){
+    PrintTestMessage("==========Message tests start==========")
+}
+
+// NewSubscribeServer starts a local server that answers the first subscribe request with
+// the connect response, the second with the response and then holds the long-poll.
+func NewSubscribeServer(response string) *httptest.Server {
+    return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        if(strings.HasSuffix(r.URL.Path, "/0/0")){
+            fmt.Fprint(w, "[[],\"13796254500000000\"]")
+        } else if(strings.HasSuffix(r.URL.Path, "/13796254500000000")){
+            fmt.Fprint(w, response)
+        } else {
+            <-r.Context().Done()
+        }
+    }))
+}
+
+// WaitForTypedMessage returns the next Message received on the channel,
+// false if none is received within the timeout.
+func WaitForTypedMessage(channel chan pubnubMessaging.Message, timeout time.Duration) (pubnubMessaging.Message, bool) {
+    select {
+        case message := <-channel:
+            return message, true
+        case <-time.After(timeout):
+            return pubnubMessaging.Message{}, false
+    }
+}
+
+// TestSubscribeMessagesMultipleChannels subscribes to two channels with SubscribeMessages,
+// each message of the response should be delivered with its own channel and the timetoken.
+func TestSubscribeMessagesMultipleChannels(t *testing.T){
+    server := NewSubscribeServer("[[\"hello\",{\"text\":\"hi\",\"count\":2}],\"13796254500000001\",\"ch1,ch2\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    messageChannel := make(chan pubnubMessaging.Message)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.SubscribeMessages("ch1,ch2", "", returnChannel, messageChannel, false, errorChannel)
+    go DrainResponse(returnChannel)
+
+    first, ok1 := WaitForTypedMessage(messageChannel, 5 * time.Second)
+    second, ok2 := WaitForTypedMessage(messageChannel, 5 * time.Second)
+    if(!ok1 || !ok2){
+        t.Fatal("Test 'SubscribeMessagesMultipleChannels': failed. Messages not received.")
+    }
+    data, _ := second.Data.(map[string]interface{})
+    if((first.Channel == "ch1") && (first.Subscription == "ch1") && (first.Data == "hello") &&
+        (string(first.Payload) == "\"hello\"") && (first.Timetoken == 13796254500000001) &&
+        (second.Channel == "ch2") && (data["text"] == "hi") && (!second.IsPresence)) {
+        fmt.Println("Test 'SubscribeMessagesMultipleChannels': passed.")
+    } else {
+        t.Error(fmt.Sprintf("Test 'SubscribeMessagesMultipleChannels': failed. %v %v", first, second))
+    }
+}
+
+// TestSubscribeMessagesPresence subscribes to the presence of a channel with SubscribeMessages,
+// the presence event should be delivered with the uuid of the user that joined.
+func TestSubscribeMessagesPresence(t *testing.T){
+    server := NewSubscribeServer("[[{\"action\":\"join\",\"uuid\":\"user1\",\"timestamp\":1379625450,\"occupancy\":1}],\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    messageChannel := make(chan pubnubMessaging.Message)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.SubscribeMessages("ch1", "", returnChannel, messageChannel, true, errorChannel)
+    go DrainResponse(returnChannel)
+
+    message, ok := WaitForTypedMessage(messageChannel, 5 * time.Second)
+    if(ok && message.IsPresence && (message.Channel == "ch1") && (message.Subscription == "ch1-pnpres") &&
+        (message.PublisherUuid == "user1")) {
+        fmt.Println("Test 'SubscribeMessagesPresence': passed.")
+    } else {
+        t.Error(fmt.Sprintf("Test 'SubscribeMessagesPresence': failed. %v", message))
+    }
+}
+
+// TestSubscribeJsonResponse subscribes with Subscribe, the message should still be
+// delivered as the json array of the message, the timetoken and the channel.
+func TestSubscribeJsonResponse(t *testing.T){
+    server := NewSubscribeServer("[[\"hello\"],\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    if(WaitForMessage(returnChannel, "[[\"hello\"],\"13796254500000001\",\"ch1\"]", 5 * time.Second)){
+        fmt.Println("Test 'SubscribeJsonResponse': passed.")
+    } else {
+        t.Error("Test 'SubscribeJsonResponse': failed.")
+    }
+}
+
+// TestMessageEnd prints a message on the screen to mark the end of
+// message tests.
+// PrintTestMessage is defined in the common.go file.
+func TestMessageEnd(t *testing.T){
+    PrintTestMessage("==========Message tests end==========")
+}
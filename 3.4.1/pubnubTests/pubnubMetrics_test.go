@@ -0,0 +1,172 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubMetrics_test.go contains the tests related to the metrics hooks
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "strings"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestMetricsStart prints a message on the screen to mark the beginning of
+// metrics tests.
+// PrintTestMessage is defined in the common.go file.
+func TestMetricsStart(t *testing.T){
+    PrintTestMessage("==========Metrics tests start==========")
+}
+
+// TestRequestMetrics requests the time from a server that responds once with invalid json,
+// the two requests, the error, the retry and the status codes should be counted.
+func TestRequestMetrics(t *testing.T){
+    server, _ := NewFailingServer(1, invalidJson, "[13796254500000001]")
+    defer server.Close()
+    metrics := pubnubMessaging.NewInMemoryMetrics()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 3)
+        config.Metrics = metrics
+    })
+
+    _, err := pubnubInstance.Time()
+    timeMetrics := metrics.Snapshot().Operations[pubnubMessaging.OperationTime]
+    if((err == nil) && (timeMetrics.Requests == 2) && (timeMetrics.Errors == 1) && (timeMetrics.Retries == 1) &&
+        (timeMetrics.StatusCodes[200] == 2) && (timeMetrics.MaxLatency > 0) && (timeMetrics.AverageLatency() > 0)){
+        fmt.Println("Test 'RequestMetrics': passed.")
+    } else {
+        t.Error("Test 'RequestMetrics': failed.", err, timeMetrics)
+    }
+}
+
+// TestMessageMetrics publishes a message and receives two on a subscription,
+// the messages should be counted per channel.
+func TestMessageMetrics(t *testing.T){
+    subscribeServer := NewSubscribeServer("[[\"hello\",\"world\"],\"13796254500000001\"]")
+    defer subscribeServer.Close()
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        if(strings.HasPrefix(r.URL.Path, "/publish/")){
+            fmt.Fprint(w, "[1,\"Sent\",\"13796254500000002\"]")
+        } else {
+            subscribeServer.Config.Handler.ServeHTTP(w, r)
+        }
+    }))
+    defer server.Close()
+    metrics := pubnubMessaging.NewInMemoryMetrics()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 3)
+        config.Metrics = metrics
+    })
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    messageChannel := make(chan pubnubMessaging.Message)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.SubscribeMessages("ch1", "", returnChannel, messageChannel, false, errorChannel)
+    WaitForTypedMessage(messageChannel, 5 * time.Second)
+    WaitForTypedMessage(messageChannel, 5 * time.Second)
+    _, err := pubnubInstance.PublishSync("ch2", "message")
+
+    snapshot := metrics.Snapshot()
+    if((err == nil) && (snapshot.MessagesReceived["ch1"] == 2) && (snapshot.MessagesPublished["ch2"] == 1) &&
+        (snapshot.Operations[pubnubMessaging.OperationSubscribe].Requests >= 2)){
+        fmt.Println("Test 'MessageMetrics': passed.")
+    } else {
+        t.Error("Test 'MessageMetrics': failed.", err, snapshot)
+    }
+}
+
+// TestDecryptFailureMetrics requests the history of a message that is not encrypted with the
+// cipher key, the decrypt failure should be counted for the channel.
+func TestDecryptFailureMetrics(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[[\"not encrypted\"],13796254500000001,13796254500000002]")
+    }))
+    defer server.Close()
+    metrics := pubnubMessaging.NewInMemoryMetrics()
+    pubnubInstance := InitWithConfig(server, "enigma", func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 3)
+        config.Metrics = metrics
+    })
+
+    pubnubInstance.HistorySync("testChannel", 10, 0, 0, false)
+    if failures := metrics.Snapshot().DecryptFailures["testChannel"]; failures == 1 {
+        fmt.Println("Test 'DecryptFailureMetrics': passed.")
+    } else {
+        t.Error("Test 'DecryptFailureMetrics': failed.", failures)
+    }
+}
+
+// TestReconnectMetrics subscribes to a closed server with a RetryPolicy of 2 attempts,
+// the reconnect attempts and the highest retry count should be counted.
+func TestReconnectMetrics(t *testing.T){
+    server := httptest.NewServer(http.NotFoundHandler())
+    metrics := pubnubMessaging.NewInMemoryMetrics()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 2)
+    config.Metrics = metrics
+    server.Close()
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    defer pubnubInstance.Abort()
+    statusChannel := make(chan pubnubMessaging.StatusEvent, 10)
+    pubnubInstance.SetStatusChannel(statusChannel)
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    WaitForStatus(statusChannel, pubnubMessaging.StatusMaxRetriesReached, 5 * time.Second)
+
+    snapshot := metrics.Snapshot()
+    subscribeMetrics := snapshot.Operations[pubnubMessaging.OperationSubscribe]
+    if((snapshot.ReconnectAttempts == 2) && (snapshot.MaxRetryCount == 2) && (subscribeMetrics.Errors == 3)){
+        fmt.Println("Test 'ReconnectMetrics': passed.")
+    } else {
+        t.Error("Test 'ReconnectMetrics': failed.", snapshot)
+    }
+}
+
+// TestReconnectMetricsOnStatusCode subscribes to a server that responds with 500 with a RetryPolicy
+// of 2 attempts, the reconnect attempts after the non 200 responses should be counted too.
+func TestReconnectMetricsOnStatusCode(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        w.WriteHeader(http.StatusInternalServerError)
+        fmt.Fprint(w, "[0,\"Internal Server Error\"]")
+    }))
+    defer server.Close()
+    metrics := pubnubMessaging.NewInMemoryMetrics()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 2)
+    config.Metrics = metrics
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    defer pubnubInstance.Abort()
+    statusChannel := make(chan pubnubMessaging.StatusEvent, 10)
+    pubnubInstance.SetStatusChannel(statusChannel)
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    WaitForStatus(statusChannel, pubnubMessaging.StatusMaxRetriesReached, 5 * time.Second)
+
+    snapshot := metrics.Snapshot()
+    if((snapshot.ReconnectAttempts == 2) && (snapshot.MaxRetryCount == 2)){
+        fmt.Println("Test 'ReconnectMetricsOnStatusCode': passed.")
+    } else {
+        t.Error("Test 'ReconnectMetricsOnStatusCode': failed.", snapshot)
+    }
+}
+
+// TestMetricsEnd prints a message on the screen to mark the end of
+// metrics tests.
+// PrintTestMessage is defined in the common.go file.
+func TestMetricsEnd(t *testing.T){
+    PrintTestMessage("==========Metrics tests end==========")
+}
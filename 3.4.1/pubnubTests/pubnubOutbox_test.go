@@ -0,0 +1,313 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubOutbox_test.go contains the tests related to the disk backed outbox of the publish requests
+package pubnubTests
+
+import (
+    "testing"
+    "context"
+    "fmt"
+    "io/ioutil"
+    "net"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestOutboxStart prints a message on the screen to mark the beginning of
+// outbox tests.
+// PrintTestMessage is defined in the common.go file.
+func TestOutboxStart(t *testing.T){
+    PrintTestMessage("==========Outbox tests start==========")
+}
+
+// NewOfflineServer creates a local server for the handler that is offline, its listener is closed
+// so that the connections to it are refused, until online is called.
+// It returns the server and the online func.
+func NewOfflineServer(handler http.Handler) (*httptest.Server, func()) {
+    server := httptest.NewUnstartedServer(handler)
+    address := server.Listener.Addr().String()
+    server.Listener.Close()
+    return server, func() {
+        listener, err := net.Listen("tcp", address)
+        if err != nil {
+            panic(err)
+        }
+        server.Listener = listener
+        server.Start()
+    }
+}
+
+// NewOfflinePublishServer creates an offline server, see NewOfflineServer, that records the message
+// of each publish request and answers it once online.
+// It returns the server, the online func and a func returning the published messages in the order received.
+func NewOfflinePublishServer() (*httptest.Server, func(), func() []string) {
+    var lock sync.Mutex
+    var published []string
+    server, online := NewOfflineServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        segments := strings.Split(r.URL.Path, "/")
+        lock.Lock()
+        published = append(published, strings.Trim(segments[len(segments) - 1], "\""))
+        lock.Unlock()
+        fmt.Fprint(w, "[1,\"Sent\",\"13796254500000001\"]")
+    }))
+    return server, online, func() []string {
+        lock.Lock()
+        defer lock.Unlock()
+        return append([]string(nil), published...)
+    }
+}
+
+// WithOutbox returns the configure func of InitWithConfig that sets the Outbox and a single retry.
+func WithOutbox(outbox *pubnubMessaging.Outbox) func(*pubnubMessaging.Config) {
+    return func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 1)
+        config.Outbox = outbox
+    }
+}
+
+// TestOutboxReplay publishes two messages while the server is offline, they should be saved to the
+// outbox file and, once the server is back, published in order with the responses sent on the
+// callback channel of their publish calls. The file should then be emptied.
+func TestOutboxReplay(t *testing.T){
+    server, online, published := NewOfflinePublishServer()
+    defer server.Close()
+    dir, _ := ioutil.TempDir("", "outbox")
+    defer os.RemoveAll(dir)
+    path := filepath.Join(dir, "outbox")
+    outbox, err := pubnubMessaging.NewOutbox(path, nil, nil)
+    if err != nil {
+        t.Fatal("Test 'OutboxReplay': failed.", err)
+    }
+    defer outbox.Close()
+    outbox.RetryInterval = 50 * time.Millisecond
+    pubnubInstance := InitWithConfig(server, "", WithOutbox(outbox))
+
+    returnChannel := make(chan []byte, 10)
+    errorChannel := make(chan []byte, 10)
+    pubnubInstance.Publish("testChannel", "m1", returnChannel, errorChannel)
+    pubnubInstance.Publish("testChannel", "m2", returnChannel, errorChannel)
+    contents, _ := ioutil.ReadFile(path)
+    saved := strings.Count(string(contents), "\n")
+    pending := outbox.Len()
+    online()
+
+    first := WaitForMessage(returnChannel, "Sent", 5 * time.Second)
+    second := WaitForMessage(returnChannel, "Sent", 5 * time.Second)
+    contents, _ = ioutil.ReadFile(path)
+    if((saved == 2) && (pending == 2) && first && second && (len(errorChannel) == 0) &&
+        (strings.Join(published(), ",") == "m1,m2") && (outbox.Len() == 0) && (len(contents) == 0)){
+        fmt.Println("Test 'OutboxReplay': passed.")
+    } else {
+        t.Error("Test 'OutboxReplay': failed.", saved, pending, first, second, published(), string(contents))
+    }
+}
+
+// TestOutboxOrdering publishes a message while the server is offline, then another one once it
+// is back before the outbox is replayed, the second message should be saved after the first one
+// and both published in order.
+func TestOutboxOrdering(t *testing.T){
+    server, online, published := NewOfflinePublishServer()
+    defer server.Close()
+    dir, _ := ioutil.TempDir("", "outbox")
+    defer os.RemoveAll(dir)
+    outbox, err := pubnubMessaging.NewOutbox(filepath.Join(dir, "outbox"), nil, nil)
+    if err != nil {
+        t.Fatal("Test 'OutboxOrdering': failed.", err)
+    }
+    defer outbox.Close()
+    outbox.RetryInterval = time.Hour
+    pubnubInstance := InitWithConfig(server, "", WithOutbox(outbox))
+
+    returnChannel := make(chan []byte, 10)
+    errorChannel := make(chan []byte, 10)
+    pubnubInstance.Publish("testChannel", "m1", returnChannel, errorChannel)
+    online()
+    pubnubInstance.Publish("testChannel", "m2", returnChannel, errorChannel)
+
+    first := WaitForMessage(returnChannel, "Sent", 5 * time.Second)
+    second := WaitForMessage(returnChannel, "Sent", 5 * time.Second)
+    if(first && second && (len(errorChannel) == 0) && (strings.Join(published(), ",") == "m1,m2") && (outbox.Len() == 0)){
+        fmt.Println("Test 'OutboxOrdering': passed.")
+    } else {
+        t.Error("Test 'OutboxOrdering': failed.", first, second, published())
+    }
+}
+
+// TestOutboxRestart saves two messages to an outbox file while the server is offline and reopens it
+// after a partial line was written, the saved messages should be replayed in order by the new instance
+// with the responses sent on the callback channel of the Outbox.
+func TestOutboxRestart(t *testing.T){
+    server, online, published := NewOfflinePublishServer()
+    defer server.Close()
+    dir, _ := ioutil.TempDir("", "outbox")
+    defer os.RemoveAll(dir)
+    path := filepath.Join(dir, "outbox")
+
+    outbox, _ := pubnubMessaging.NewOutbox(path, nil, nil)
+    outbox.RetryInterval = time.Hour
+    pubnubInstance := InitWithConfig(server, "", WithOutbox(outbox))
+    pubnubInstance.Publish("testChannel", "m1", nil, nil)
+    pubnubInstance.Publish("testChannel", "m2", nil, nil)
+    outbox.Close()
+    file, _ := os.OpenFile(path, os.O_WRONLY | os.O_APPEND, 0600)
+    file.WriteString("{\"id\":\"partial")
+    file.Close()
+
+    online()
+    returnChannel := make(chan []byte, 10)
+    restarted, err := pubnubMessaging.NewOutbox(path, returnChannel, nil)
+    if err != nil {
+        t.Fatal("Test 'OutboxRestart': failed.", err)
+    }
+    defer restarted.Close()
+    pending := restarted.Len()
+    InitWithConfig(server, "", WithOutbox(restarted))
+
+    first := WaitForMessage(returnChannel, "Sent", 5 * time.Second)
+    second := WaitForMessage(returnChannel, "Sent", 5 * time.Second)
+    if((pending == 2) && first && second && (strings.Join(published(), ",") == "m1,m2")){
+        fmt.Println("Test 'OutboxRestart': passed.")
+    } else {
+        t.Error("Test 'OutboxRestart': failed.", pending, first, second, published())
+    }
+}
+
+// TestOutboxRestartWithOptions saves a message published by POST with the response body skipped
+// while the server is offline and reopens the outbox file, the message should be replayed with
+// the same options.
+func TestOutboxRestartWithOptions(t *testing.T){
+    var lock sync.Mutex
+    var methods []string
+    server, online := NewOfflineServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        lock.Lock()
+        methods = append(methods, r.Method)
+        lock.Unlock()
+        fmt.Fprint(w, "[1,\"Sent\",\"13796254500000001\"]")
+    }))
+    defer server.Close()
+    dir, _ := ioutil.TempDir("", "outbox")
+    defer os.RemoveAll(dir)
+    path := filepath.Join(dir, "outbox")
+
+    outbox, _ := pubnubMessaging.NewOutbox(path, nil, nil)
+    outbox.RetryInterval = time.Hour
+    pubnubInstance := InitWithConfig(server, "", WithOutbox(outbox))
+    options := pubnubMessaging.PublishOptions{Method: pubnubMessaging.PublishMethodPost, SkipResponseBody: true}
+    pubnubInstance.PublishWithOptions(context.Background(), "testChannel", "m1", options, nil, nil)
+    outbox.Close()
+
+    online()
+    returnChannel := make(chan []byte, 10)
+    restarted, err := pubnubMessaging.NewOutbox(path, returnChannel, nil)
+    if err != nil {
+        t.Fatal("Test 'OutboxRestartWithOptions': failed.", err)
+    }
+    defer restarted.Close()
+    InitWithConfig(server, "", WithOutbox(restarted))
+
+    var response string
+    select {
+        case value := <-returnChannel:
+            response = string(value)
+        case <-time.After(5 * time.Second):
+    }
+    lock.Lock()
+    defer lock.Unlock()
+    if((response == "[1,\"Sent\"]") && (strings.Join(methods, ",") == "POST")){
+        fmt.Println("Test 'OutboxRestartWithOptions': passed.")
+    } else {
+        t.Error("Test 'OutboxRestartWithOptions': failed.", response, methods)
+    }
+}
+
+// TestOutboxCloseWithUnreadResponse saves a message while the server is offline with a callback
+// channel that is never read, Close should not wait for the response of the replay to be read.
+func TestOutboxCloseWithUnreadResponse(t *testing.T){
+    server, online, published := NewOfflinePublishServer()
+    defer server.Close()
+    dir, _ := ioutil.TempDir("", "outbox")
+    defer os.RemoveAll(dir)
+    outbox, _ := pubnubMessaging.NewOutbox(filepath.Join(dir, "outbox"), nil, nil)
+    outbox.RetryInterval = 50 * time.Millisecond
+    pubnubInstance := InitWithConfig(server, "", WithOutbox(outbox))
+
+    pubnubInstance.Publish("testChannel", "m1", make(chan []byte), nil)
+    online()
+    for deadline := time.Now().Add(5 * time.Second); (len(published()) == 0) && time.Now().Before(deadline); {
+        time.Sleep(10 * time.Millisecond)
+    }
+    closed := make(chan error)
+    go func(){
+        closed <- outbox.Close()
+    }()
+    select {
+        case <-closed:
+            fmt.Println("Test 'OutboxCloseWithUnreadResponse': passed.")
+        case <-time.After(5 * time.Second):
+            t.Error("Test 'OutboxCloseWithUnreadResponse': failed.", published())
+    }
+}
+
+// TestOutboxNotSavedAfterSent publishes a message to a server that closes the connection after
+// reading the request, the origin may have stored the message so it should not be saved to the
+// outbox nor sent again, and the error should be sent on the error channel.
+func TestOutboxNotSavedAfterSent(t *testing.T){
+    var requests int32
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        atomic.AddInt32(&requests, 1)
+        closeConnection(w, r)
+    }))
+    defer server.Close()
+    dir, _ := ioutil.TempDir("", "outbox")
+    defer os.RemoveAll(dir)
+    outbox, _ := pubnubMessaging.NewOutbox(filepath.Join(dir, "outbox"), nil, nil)
+    defer outbox.Close()
+    outbox.RetryInterval = 50 * time.Millisecond
+    pubnubInstance := InitWithConfig(server, "", WithOutbox(outbox))
+
+    errorChannel := make(chan []byte, 10)
+    pubnubInstance.Publish("testChannel", "m1", nil, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    time.Sleep(200 * time.Millisecond)
+    if((pubnubError != nil) && (outbox.Len() == 0) && (atomic.LoadInt32(&requests) == 1)){
+        fmt.Println("Test 'OutboxNotSavedAfterSent': passed.")
+    } else {
+        t.Error("Test 'OutboxNotSavedAfterSent': failed.", pubnubError, outbox.Len(), atomic.LoadInt32(&requests))
+    }
+}
+
+// TestOutboxNotShared creates two instances with the same Outbox, only the first one should use it.
+func TestOutboxNotShared(t *testing.T){
+    server, _, _ := NewOfflinePublishServer()
+    defer server.Close()
+    dir, _ := ioutil.TempDir("", "outbox")
+    defer os.RemoveAll(dir)
+    outbox, _ := pubnubMessaging.NewOutbox(filepath.Join(dir, "outbox"), nil, nil)
+    defer outbox.Close()
+    outbox.RetryInterval = time.Hour
+
+    first := InitWithConfig(server, "", WithOutbox(outbox))
+    second := InitWithConfig(server, "", WithOutbox(outbox))
+    errorChannel := make(chan []byte, 10)
+    second.Publish("testChannel", "m1", nil, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    if((first.Config().Outbox == outbox) && (second.Config().Outbox == nil) && (outbox.Len() == 0) &&
+        (pubnubError != nil) && (pubnubMessaging.ErrorCategory(pubnubError.Err) == pubnubMessaging.ErrorCategoryNetworkUnavailable)){
+        fmt.Println("Test 'OutboxNotShared': passed.")
+    } else {
+        t.Error("Test 'OutboxNotShared': failed.", second.Config().Outbox, outbox.Len(), pubnubError)
+    }
+}
+
+// TestOutboxEnd prints a message on the screen to mark the end of
+// outbox tests.
+// PrintTestMessage is defined in the common.go file.
+func TestOutboxEnd(t *testing.T){
+    PrintTestMessage("==========Outbox tests end==========")
+}
@@ -0,0 +1,206 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubPublishOptions_test.go contains the tests related to the publish options
+package pubnubTests
+
+import (
+    "testing"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "strings"
+    "sync"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestPublishOptionsStart prints a message on the screen to mark the beginning of
+// publish options tests.
+// PrintTestMessage is defined in the common.go file.
+func TestPublishOptionsStart(t *testing.T){
+    PrintTestMessage("==========Publish options tests start==========")
+}
+
+// PublishRequest is a publish request received by the PublishServer.
+type PublishRequest struct {
+    Method        string
+    ContentType   string
+    Path          string
+    Query         map[string][]string
+    Body          string
+}
+
+// NewPublishServer starts a local server that records the publish requests and answers them
+// with a sent response.
+func NewPublishServer() (*httptest.Server, func() []PublishRequest) {
+    var lock sync.Mutex
+    var requests []PublishRequest
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        body, _ := ioutil.ReadAll(r.Body)
+        lock.Lock()
+        requests = append(requests, PublishRequest{Method: r.Method, ContentType: r.Header.Get("Content-Type"),
+            Path: r.URL.Path, Query: r.URL.Query(), Body: string(body)})
+        lock.Unlock()
+        fmt.Fprint(w, "[1,\"Sent\",\"13796254500000001\"]")
+    }))
+    return server, func() []PublishRequest {
+        lock.Lock()
+        defer lock.Unlock()
+        return append([]PublishRequest(nil), requests...)
+    }
+}
+
+// TestPublishPost publishes a message with the POST method, the message should be sent as the
+// json body to the publish url without the message.
+func TestPublishPost(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.PublishPostThreshold = 0
+    })
+
+    result, err := pubnubInstance.PublishSyncWithOptions(context.Background(), "testChannel", "message",
+        pubnubMessaging.PublishOptions{Method: pubnubMessaging.PublishMethodPost})
+    sent := requests()
+    if((err == nil) && (result.Timetoken == 13796254500000001) && (len(sent) == 1) && (sent[0].Method == "POST") &&
+        (sent[0].ContentType == "application/json") && (sent[0].Path == "/publish/demo/demo/0/testChannel/0") &&
+        (sent[0].Body == "\"message\"") && (sent[0].Query["messageid"][0] == result.MessageId)){
+        fmt.Println("Test 'PublishPost': passed.")
+    } else {
+        t.Error("Test 'PublishPost': failed.", err, sent)
+    }
+}
+
+// TestPublishPostAboveThreshold publishes a small and a large message with a threshold of 16 bytes,
+// only the large message should be sent by POST, and a forced GET should not.
+func TestPublishPostAboveThreshold(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.PublishPostThreshold = 16
+    })
+
+    large := strings.Repeat("x", 32)
+    _, err1 := pubnubInstance.PublishSync("testChannel", "small")
+    _, err2 := pubnubInstance.PublishSync("testChannel", large)
+    _, err3 := pubnubInstance.PublishSyncWithOptions(context.Background(), "testChannel", large,
+        pubnubMessaging.PublishOptions{Method: pubnubMessaging.PublishMethodGet})
+    sent := requests()
+    if((err1 == nil) && (err2 == nil) && (err3 == nil) && (len(sent) == 3) && (sent[0].Method == "GET") &&
+        (sent[1].Method == "POST") && (sent[1].Body == "\"" + large + "\"") &&
+        (sent[2].Method == "GET") && strings.HasSuffix(sent[2].Path, "/0/\"" + large + "\"")){
+        fmt.Println("Test 'PublishPostAboveThreshold': passed.")
+    } else {
+        t.Error("Test 'PublishPostAboveThreshold': failed.", err1, err2, err3, sent)
+    }
+}
+
+// TestPublishPostEncrypted publishes a message by POST with a cipher key,
+// the body should be the encrypted message.
+func TestPublishPostEncrypted(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "enigma", func(config *pubnubMessaging.Config){
+        config.PublishPostThreshold = 0
+    })
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.PublishWithOptions(context.Background(), "testChannel", "message",
+        pubnubMessaging.PublishOptions{Method: pubnubMessaging.PublishMethodPost}, returnChannel, errorChannel)
+    if(!WaitForMessage(returnChannel, "Sent", 5 * time.Second)){
+        t.Fatal("Test 'PublishPostEncrypted': failed. No response.")
+    }
+    sent := requests()
+    var encrypted string
+    json.Unmarshal([]byte(sent[0].Body), &encrypted)
+    decrypted, err := pubnubMessaging.DecryptString("enigma", encrypted)
+    if((err == nil) && (sent[0].Method == "POST") && (decrypted == "\"message\"")){
+        fmt.Println("Test 'PublishPostEncrypted': passed.")
+    } else {
+        t.Error("Test 'PublishPostEncrypted': failed.", err, decrypted, sent)
+    }
+}
+
+// TestPublishStorageOptions publishes a message with the storage disabled, a TTL, the metadata and
+// no replication, the options should be sent as query parameters with the message id.
+func TestPublishStorageOptions(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.PublishPostThreshold = 0
+    })
+
+    options := pubnubMessaging.PublishOptions{
+        Store:           pubnubMessaging.PublishStoreDisabled,
+        TTL:             24,
+        Meta:            map[string]interface{}{"type": "cursor"},
+        NoReplication:   true,
+    }
+    result, err := pubnubInstance.PublishSyncWithOptions(context.Background(), "testChannel", "message", options)
+    sent := requests()
+    if((err == nil) && (len(sent) == 1) && (sent[0].Path == "/publish/demo/demo/0/testChannel/0/\"message\"") &&
+        (sent[0].Query["store"][0] == "0") && (sent[0].Query["ttl"][0] == "24") &&
+        (sent[0].Query["meta"][0] == "{\"type\":\"cursor\"}") && (sent[0].Query["norep"][0] == "true") &&
+        (sent[0].Query["messageid"][0] == result.MessageId)){
+        fmt.Println("Test 'PublishStorageOptions': passed.")
+    } else {
+        t.Error("Test 'PublishStorageOptions': failed.", err, sent)
+    }
+}
+
+// TestPublishOptionsSigned publishes a message with a secret key and the storage enabled by GET and
+// by POST, the signature should cover the query parameters of the options, but not the messageid
+// sent with them, with both methods.
+func TestPublishOptionsSigned(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "secret", "", "", config)
+
+    options := pubnubMessaging.PublishOptions{Store: pubnubMessaging.PublishStoreEnabled}
+    result1, err1 := pubnubInstance.PublishSyncWithOptions(context.Background(), "testChannel", "message", options)
+    options.Method = pubnubMessaging.PublishMethodPost
+    result2, err2 := pubnubInstance.PublishSyncWithOptions(context.Background(), "testChannel", "message", options)
+    signature1 := pubnubMessaging.GetHmacSha256("secret", "demo/demo/secret/testChannel/message?store=1")
+    signature2 := pubnubMessaging.GetHmacSha256("secret", "demo/demo/secret/testChannel/message?store=1")
+    sent := requests()
+    if((err1 == nil) && (err2 == nil) && (len(sent) == 2) &&
+        strings.HasPrefix(sent[0].Path, "/publish/demo/demo/" + signature1 + "/testChannel/0/") && (sent[0].Query["store"][0] == "1") &&
+        (sent[0].Query["messageid"][0] == result1.MessageId) &&
+        (sent[1].Path == "/publish/demo/demo/" + signature2 + "/testChannel/0") && (sent[1].Query["store"][0] == "1") &&
+        (sent[1].Query["messageid"][0] == result2.MessageId)){
+        fmt.Println("Test 'PublishOptionsSigned': passed.")
+    } else {
+        t.Error("Test 'PublishOptionsSigned': failed.", err1, err2, sent)
+    }
+}
+
+// TestPublishInvalidTTL publishes a message with a negative TTL, the publish should fail without
+// sending the request.
+func TestPublishInvalidTTL(t *testing.T){
+    server, requests := NewPublishServer()
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.PublishPostThreshold = 0
+    })
+
+    _, err := pubnubInstance.PublishSyncWithOptions(context.Background(), "testChannel", "message",
+        pubnubMessaging.PublishOptions{TTL: -1})
+    if((err != nil) && (len(requests()) == 0)){
+        fmt.Println("Test 'PublishInvalidTTL': passed.")
+    } else {
+        t.Error("Test 'PublishInvalidTTL': failed.", err, requests())
+    }
+}
+
+// TestPublishOptionsEnd prints a message on the screen to mark the end of
+// publish options tests.
+// PrintTestMessage is defined in the common.go file.
+func TestPublishOptionsEnd(t *testing.T){
+    PrintTestMessage("==========Publish options tests end==========")
+}
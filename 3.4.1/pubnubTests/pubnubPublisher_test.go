@@ -0,0 +1,240 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubPublisher_test.go contains the tests related to the background publish queue
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestPublisherStart prints a message on the screen to mark the beginning of
+// publisher tests.
+// PrintTestMessage is defined in the common.go file.
+func TestPublisherStart(t *testing.T){
+    PrintTestMessage("==========Publisher tests start==========")
+}
+
+// NewHeldPublishServer starts a local server that records the channel and the message of each
+// publish request, sends the message on the received channel and answers once release is closed.
+// It returns the server and a func returning the "channel:message" entries in the order received.
+func NewHeldPublishServer(received chan string, release chan struct{}) (*httptest.Server, func() []string) {
+    var lock sync.Mutex
+    var published []string
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        segments := strings.Split(r.URL.Path, "/")
+        entry := segments[len(segments) - 3] + ":" + strings.Trim(segments[len(segments) - 1], "\"")
+        lock.Lock()
+        published = append(published, entry)
+        lock.Unlock()
+        if(received != nil){
+            received <- entry
+        }
+        <-release
+        fmt.Fprint(w, "[1,\"Sent\",\"13796254500000001\"]")
+    }))
+    return server, func() []string {
+        lock.Lock()
+        defer lock.Unlock()
+        return append([]string(nil), published...)
+    }
+}
+
+// TestPublisherOrdering publishes the messages of two channels with 4 workers, the messages of each
+// channel should be published one at a time in their order and Flush should wait for all of them.
+func TestPublisherOrdering(t *testing.T){
+    var lock sync.Mutex
+    inFlight := make(map[string]int)
+    overlapped := false
+    published := make(map[string][]string)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        segments := strings.Split(r.URL.Path, "/")
+        channel := segments[len(segments) - 3]
+        lock.Lock()
+        inFlight[channel]++
+        overlapped = overlapped || (inFlight[channel] > 1)
+        published[channel] = append(published[channel], segments[len(segments) - 1])
+        lock.Unlock()
+        time.Sleep(5 * time.Millisecond)
+        lock.Lock()
+        inFlight[channel]--
+        lock.Unlock()
+        fmt.Fprint(w, "[1,\"Sent\",\"13796254500000001\"]")
+    }))
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    responses := make(chan []byte, 100)
+    publisher := pubnubInstance.NewPublisher(pubnubMessaging.PublisherConfig{Workers: 4}, responses, nil)
+    defer publisher.Close()
+    for i := 0; i < 10; i++ {
+        publisher.Publish("ch1", i)
+        publisher.Publish("ch2", i)
+    }
+    publisher.Flush()
+    lock.Lock()
+    defer lock.Unlock()
+    expected := "0,1,2,3,4,5,6,7,8,9"
+    if((len(responses) == 20) && !overlapped && (strings.Join(published["ch1"], ",") == expected) &&
+        (strings.Join(published["ch2"], ",") == expected)){
+        fmt.Println("Test 'PublisherOrdering': passed.")
+    } else {
+        t.Error("Test 'PublisherOrdering': failed.", len(responses), overlapped, published)
+    }
+}
+
+// TestPublisherReadyChannels queues messages of a channel whose first message is held by the server,
+// then a message of another channel. The message of the other channel should be published by the
+// second worker without waiting, and the held channel should keep its order.
+func TestPublisherReadyChannels(t *testing.T){
+    received := make(chan string, 10)
+    release := make(chan struct{})
+    server, published := NewHeldPublishServer(received, release)
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    publisher := pubnubInstance.NewPublisher(pubnubMessaging.PublisherConfig{Workers: 2}, nil, nil)
+    publisher.Publish("ch1", "m1")
+    <-received
+    publisher.Publish("ch1", "m2")
+    publisher.Publish("ch1", "m3")
+    publisher.Publish("ch2", "n1")
+    var entry string
+    select {
+        case entry = <-received:
+        case <-time.After(5 * time.Second):
+    }
+    close(release)
+    publisher.Close()
+    entries := strings.Join(published(), ",")
+    if((entry == "ch2:n1") && (strings.Replace(entries, "ch2:n1,", "", 1) == "ch1:m1,ch1:m2,ch1:m3")){
+        fmt.Println("Test 'PublisherReadyChannels': passed.")
+    } else {
+        t.Error("Test 'PublisherReadyChannels': failed.", entry, entries)
+    }
+}
+
+// TestPublisherBatching queues messages of a channel whose first message is held by the server with
+// a BatchSize of 2, the queued messages should be published two at a time as json arrays in order.
+func TestPublisherBatching(t *testing.T){
+    received := make(chan string, 10)
+    release := make(chan struct{})
+    server, published := NewHeldPublishServer(received, release)
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    publisher := pubnubInstance.NewPublisher(pubnubMessaging.PublisherConfig{Workers: 1, BatchSize: 2}, nil, nil)
+    publisher.Publish("ch1", "m1")
+    <-received
+    publisher.Publish("ch1", "m2")
+    publisher.Publish("ch1", "m3")
+    publisher.Publish("ch1", "m4")
+    close(release)
+    publisher.Close()
+    entries := strings.Join(published(), ",")
+    if(entries == "ch1:[\"m1\"],ch1:[\"m2\",\"m3\"],ch1:[\"m4\"]"){
+        fmt.Println("Test 'PublisherBatching': passed.")
+    } else {
+        t.Error("Test 'PublisherBatching': failed.", entries)
+    }
+}
+
+// TestPublisherOverflowError fills the queue of a single worker Publisher while the first message
+// is held by the server, Publish should return a QueueFullError and Close should publish the
+// queued message.
+func TestPublisherOverflowError(t *testing.T){
+    received := make(chan string, 10)
+    release := make(chan struct{})
+    server, published := NewHeldPublishServer(received, release)
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    publisher := pubnubInstance.NewPublisher(pubnubMessaging.PublisherConfig{QueueSize: 1, Workers: 1,
+        OverflowPolicy: pubnubMessaging.OverflowError}, nil, nil)
+    publisher.Publish("ch1", "m1")
+    <-received
+    err1 := publisher.Publish("ch1", "m2")
+    err2 := publisher.Publish("ch1", "m3")
+    close(release)
+    publisher.Close()
+    err3 := publisher.Publish("ch1", "m4")
+    _, full := err2.(*pubnubMessaging.QueueFullError)
+    if((err1 == nil) && full && (err3 != nil) && (strings.Join(published(), ",") == "ch1:m1,ch1:m2")){
+        fmt.Println("Test 'PublisherOverflowError': passed.")
+    } else {
+        t.Error("Test 'PublisherOverflowError': failed.", err1, err2, err3, published())
+    }
+}
+
+// TestPublisherDropOldest fills the queue of a single worker Publisher with the OverflowDropOldest
+// policy, the oldest queued message should be dropped and reported on the error channel.
+func TestPublisherDropOldest(t *testing.T){
+    received := make(chan string, 10)
+    release := make(chan struct{})
+    server, published := NewHeldPublishServer(received, release)
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    errorChannel := make(chan []byte, 10)
+    publisher := pubnubInstance.NewPublisher(pubnubMessaging.PublisherConfig{QueueSize: 1, Workers: 1,
+        OverflowPolicy: pubnubMessaging.OverflowDropOldest}, nil, errorChannel)
+    publisher.Publish("ch1", "m1")
+    <-received
+    publisher.Publish("ch2", "m2")
+    err := publisher.Publish("ch1", "m3")
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    close(release)
+    publisher.Close()
+    if((err == nil) && (pubnubError != nil) && (pubnubError.Channel == "ch2") &&
+        (pubnubMessaging.ErrorCategory(pubnubError.Err) == pubnubMessaging.ErrorCategoryQueueFull) &&
+        (strings.Join(published(), ",") == "ch1:m1,ch1:m3")){
+        fmt.Println("Test 'PublisherDropOldest': passed.")
+    } else {
+        t.Error("Test 'PublisherDropOldest': failed.", err, pubnubError, published())
+    }
+}
+
+// TestPublisherBlock fills the queue of a single worker Publisher with the OverflowBlock policy,
+// Publish should wait until the held message is answered and Close should publish all the messages.
+func TestPublisherBlock(t *testing.T){
+    received := make(chan string, 10)
+    release := make(chan struct{})
+    server, published := NewHeldPublishServer(received, release)
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+
+    publisher := pubnubInstance.NewPublisher(pubnubMessaging.PublisherConfig{QueueSize: 1, Workers: 1}, nil, nil)
+    publisher.Publish("ch1", "m1")
+    <-received
+    publisher.Publish("ch1", "m2")
+    returned := make(chan error)
+    go func(){
+        returned <- publisher.Publish("ch1", "m3")
+    }()
+    blocked := false
+    select {
+        case <-returned:
+        case <-time.After(200 * time.Millisecond):
+            blocked = true
+    }
+    close(release)
+    err := <-returned
+    publisher.Close()
+    if(blocked && (err == nil) && (strings.Join(published(), ",") == "ch1:m1,ch1:m2,ch1:m3")){
+        fmt.Println("Test 'PublisherBlock': passed.")
+    } else {
+        t.Error("Test 'PublisherBlock': failed.", blocked, err, published())
+    }
+}
+
+// TestPublisherEnd prints a message on the screen to mark the end of
+// publisher tests.
+// PrintTestMessage is defined in the common.go file.
+func TestPublisherEnd(t *testing.T){
+    PrintTestMessage("==========Publisher tests end==========")
+}
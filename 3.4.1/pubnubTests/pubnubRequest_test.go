@@ -0,0 +1,206 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubRequest_test.go contains the tests related to the retries of the non subscribe requests
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "sync"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestRequestStart prints a message on the screen to mark the beginning of
+// request retry tests.
+// PrintTestMessage is defined in the common.go file.
+func TestRequestStart(t *testing.T){
+    PrintTestMessage("==========Request retry tests start==========")
+}
+
+// NewFailingServer creates a test server that responds with the failure handler to the
+// first failures requests and with the response to the others. The requests are recorded.
+func NewFailingServer(failures int, failure http.HandlerFunc, response string) (*httptest.Server, func() []*http.Request) {
+    var lock sync.Mutex
+    var requests []*http.Request
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        lock.Lock()
+        requests = append(requests, r)
+        count := len(requests)
+        lock.Unlock()
+        if(count <= failures){
+            failure(w, r)
+            return
+        }
+        fmt.Fprint(w, response)
+    }))
+    return server, func() []*http.Request {
+        lock.Lock()
+        defer lock.Unlock()
+        return append([]*http.Request(nil), requests...)
+    }
+}
+
+// invalidJson responds with an invalid json.
+func invalidJson(w http.ResponseWriter, r *http.Request){
+    fmt.Fprint(w, "[1,")
+}
+
+// closeConnection closes the connection without a response.
+func closeConnection(w http.ResponseWriter, r *http.Request){
+    conn, _, err := w.(http.Hijacker).Hijack()
+    if err == nil {
+        conn.Close()
+    }
+}
+
+// TestTimeRetriedOnInvalidJson requests the time from a server that responds twice with
+// invalid json, the third attempt should succeed.
+func TestTimeRetriedOnInvalidJson(t *testing.T){
+    server, requests := NewFailingServer(2, invalidJson, "[13796254500000001]")
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 3)
+    })
+
+    timetoken, err := pubnubInstance.Time()
+    if((err == nil) && (timetoken == 13796254500000001) && (len(requests()) == 3)){
+        fmt.Println("Test 'TimeRetriedOnInvalidJson': passed.")
+    } else {
+        t.Error("Test 'TimeRetriedOnInvalidJson': failed.", timetoken, err, len(requests()))
+    }
+}
+
+// TestHistoryRetriesExhausted requests the history from a server that always responds with
+// invalid json with a RequestRetryPolicy of 2 retries, the error channel should receive
+// an InvalidJsonError after 3 attempts.
+func TestHistoryRetriesExhausted(t *testing.T){
+    server, requests := NewFailingServer(100, invalidJson, "")
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 2)
+    })
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go pubnubInstance.History("testChannel", 10, 0, 0, false, returnChannel, errorChannel)
+    pubnubError := WaitForError(errorChannel, 5 * time.Second)
+    if(pubnubError == nil){
+        t.Fatal("Test 'HistoryRetriesExhausted': failed. No structured error.")
+    }
+    if _, ok := pubnubError.Err.(*pubnubMessaging.InvalidJsonError); ok && (len(requests()) == 3) {
+        fmt.Println("Test 'HistoryRetriesExhausted': passed.")
+    } else {
+        t.Error("Test 'HistoryRetriesExhausted': failed.", pubnubError, len(requests()))
+    }
+}
+
+// TestPublishRetriedWithSameMessageId publishes to a server that closes the first connection with
+// the NetworkUnavailable category in the RetryableErrors of publish, the retry should succeed and
+// both attempts should be sent with the same message id.
+func TestPublishRetriedWithSameMessageId(t *testing.T){
+    server, requests := NewFailingServer(1, closeConnection, "[1,\"Sent\",\"13796254500000001\"]")
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 3)
+    config.RetryableErrors = pubnubMessaging.DefaultRetryableErrors()
+    config.RetryableErrors[pubnubMessaging.OperationPublish] = []string{pubnubMessaging.ErrorCategoryNetworkUnavailable}
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+
+    result, err := pubnubInstance.PublishSync("testChannel", "message")
+    sent := requests()
+    if((err == nil) && (len(sent) == 2) && (result.MessageId != "") &&
+        (sent[0].URL.Query().Get("messageid") == result.MessageId) &&
+        (sent[1].URL.Query().Get("messageid") == result.MessageId)){
+        fmt.Println("Test 'PublishRetriedWithSameMessageId': passed.")
+    } else {
+        t.Error("Test 'PublishRetriedWithSameMessageId': failed.", result, err, len(sent))
+    }
+}
+
+// TestPublishNotRetriedAfterSent publishes to a server that closes the connection once the request
+// is received, the request should not be retried by default since the message may be stored.
+func TestPublishNotRetriedAfterSent(t *testing.T){
+    server, requests := NewFailingServer(1, closeConnection, "[1,\"Sent\",\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 3)
+    })
+
+    _, err := pubnubInstance.PublishSync("testChannel", "message")
+    if _, ok := err.(*pubnubMessaging.NetworkUnavailableError); ok && (len(requests()) == 1) {
+        fmt.Println("Test 'PublishNotRetriedAfterSent': passed.")
+    } else {
+        t.Error("Test 'PublishNotRetriedAfterSent': failed.", err, len(requests()))
+    }
+}
+
+// TestPublishRetriedOnConnectFailure publishes to a closed server, the connection can't be
+// established so the request should be retried by default.
+func TestPublishRetriedOnConnectFailure(t *testing.T){
+    server := httptest.NewServer(http.NotFoundHandler())
+    server.Close()
+    metrics := pubnubMessaging.NewInMemoryMetrics()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 2)
+    config.Metrics = metrics
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+
+    _, err := pubnubInstance.PublishSync("testChannel", "message")
+    publishMetrics := metrics.Snapshot().Operations[pubnubMessaging.OperationPublish]
+    if _, ok := err.(*pubnubMessaging.NetworkUnavailableError); ok && pubnubMessaging.IsConnectError(err) &&
+        (publishMetrics.Retries == 2) {
+        fmt.Println("Test 'PublishRetriedOnConnectFailure': passed.")
+    } else {
+        t.Error("Test 'PublishRetriedOnConnectFailure': failed.", err, publishMetrics.Retries)
+    }
+}
+
+// TestAccessDeniedNotRetried publishes to a server that responds with 403,
+// the request should not be retried.
+func TestAccessDeniedNotRetried(t *testing.T){
+    server, requests := NewFailingServer(100, func(w http.ResponseWriter, r *http.Request){
+        w.WriteHeader(http.StatusForbidden)
+        fmt.Fprint(w, "{\"status\":403,\"message\":\"Forbidden\",\"error\":true}")
+    }, "")
+    defer server.Close()
+    pubnubInstance := InitWithConfig(server, "", func(config *pubnubMessaging.Config){
+        config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 3)
+    })
+
+    _, err := pubnubInstance.PublishSync("testChannel", "message")
+    if _, ok := err.(*pubnubMessaging.AccessDeniedError); ok && (len(requests()) == 1) {
+        fmt.Println("Test 'AccessDeniedNotRetried': passed.")
+    } else {
+        t.Error("Test 'AccessDeniedNotRetried': failed.", err, len(requests()))
+    }
+}
+
+// TestRetryableErrorsPerOperation removes the InvalidJson category from the RetryableErrors of
+// the Time operation, the invalid json response should not be retried.
+func TestRetryableErrorsPerOperation(t *testing.T){
+    server, requests := NewFailingServer(1, invalidJson, "[13796254500000001]")
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RetryableErrors = pubnubMessaging.DefaultRetryableErrors()
+    config.RetryableErrors[pubnubMessaging.OperationTime] = []string{pubnubMessaging.ErrorCategoryNetworkUnavailable}
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+
+    _, err := pubnubInstance.Time()
+    if _, ok := err.(*pubnubMessaging.InvalidJsonError); ok && (len(requests()) == 1) {
+        fmt.Println("Test 'RetryableErrorsPerOperation': passed.")
+    } else {
+        t.Error("Test 'RetryableErrorsPerOperation': failed.", err, len(requests()))
+    }
+}
+
+// TestRequestEnd prints a message on the screen to mark the end of
+// request retry tests.
+// PrintTestMessage is defined in the common.go file.
+func TestRequestEnd(t *testing.T){
+    PrintTestMessage("==========Request retry tests end==========")
+}
@@ -0,0 +1,101 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubRetry_test.go contains the tests related to the retry policies of the reconnects
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestRetryStart prints a message on the screen to mark the beginning of
+// retry tests.
+// PrintTestMessage is defined in the common.go file.
+func TestRetryStart(t *testing.T){
+    PrintTestMessage("==========Retry tests start==========")
+}
+
+// TestLinearRetryPolicy checks the delay of a LinearRetryPolicy and that it gives up
+// after the MaxRetries attempts, or never with UnlimitedRetries.
+func TestLinearRetryPolicy(t *testing.T){
+    policy := pubnubMessaging.NewLinearRetryPolicy(2 * time.Second, 3)
+    delay, ok := policy.Delay(3)
+    _, exhausted := policy.Delay(4)
+    unlimited := pubnubMessaging.NewLinearRetryPolicy(time.Second, pubnubMessaging.UnlimitedRetries)
+    _, unlimitedOk := unlimited.Delay(1000000)
+    if(ok && (delay == 2 * time.Second) && !exhausted && unlimitedOk){
+        fmt.Println("Test 'LinearRetryPolicy': passed.")
+    } else {
+        t.Error("Test 'LinearRetryPolicy': failed.", delay, ok, exhausted, unlimitedOk)
+    }
+}
+
+// TestExponentialRetryPolicy checks that the delay of an ExponentialRetryPolicy doubles with each
+// attempt up to the MaxDelay and that the jitter keeps it between the half and the whole of it.
+func TestExponentialRetryPolicy(t *testing.T){
+    policy := pubnubMessaging.NewExponentialRetryPolicy(time.Second, 10 * time.Second, pubnubMessaging.UnlimitedRetries)
+    expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+    for i, max := range expected {
+        for j := 0; j < 20; j++ {
+            delay, ok := policy.Delay(i + 1)
+            if(!ok || (delay > max) || (delay < max / 2)){
+                t.Fatal(fmt.Sprintf("Test 'ExponentialRetryPolicy': failed. Attempt %d: %v", i + 1, delay))
+            }
+        }
+    }
+    if _, ok := policy.Delay(1000); !ok {
+        t.Fatal("Test 'ExponentialRetryPolicy': failed. Unlimited retries.")
+    }
+    fmt.Println("Test 'ExponentialRetryPolicy': passed.")
+}
+
+// TestMaxRetriesReached subscribes to a closed server with a RetryPolicy of 2 attempts,
+// the status channel should receive 3 Disconnected events and then MaxRetriesReached.
+func TestMaxRetriesReached(t *testing.T){
+    server := httptest.NewServer(http.NotFoundHandler())
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = server.URL[len("http://"):]
+    config.RetryPolicy = pubnubMessaging.NewLinearRetryPolicy(10 * time.Millisecond, 2)
+    server.Close()
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    defer pubnubInstance.Abort()
+    statusChannel := make(chan pubnubMessaging.StatusEvent, 10)
+    pubnubInstance.SetStatusChannel(statusChannel)
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+
+    disconnected := 0
+    timeout := time.After(5 * time.Second)
+    for {
+        select {
+            case event := <-statusChannel:
+                switch event.Category {
+                    case pubnubMessaging.StatusDisconnected:
+                        disconnected++
+                    case pubnubMessaging.StatusMaxRetriesReached:
+                        if((disconnected == 3) && (event.RetryCount == 3) && (len(pubnubInstance.SubscribedChannels()) == 0)){
+                            fmt.Println("Test 'MaxRetriesReached': passed.")
+                        } else {
+                            t.Error("Test 'MaxRetriesReached': failed.", disconnected, event)
+                        }
+                        return
+                }
+            case <-timeout:
+                t.Fatal("Test 'MaxRetriesReached': failed. Disconnected: ", disconnected)
+        }
+    }
+}
+
+// TestRetryEnd prints a message on the screen to mark the end of
+// retry tests.
+// PrintTestMessage is defined in the common.go file.
+func TestRetryEnd(t *testing.T){
+    PrintTestMessage("==========Retry tests end==========")
+}
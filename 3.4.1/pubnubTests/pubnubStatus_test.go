@@ -0,0 +1,132 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubStatus_test.go contains the tests related to the status events
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "time"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestStatusStart prints a message on the screen to mark the beginning of
+// status tests.
+// PrintTestMessage is defined in the common.go file.
+func TestStatusStart(t *testing.T){
+    PrintTestMessage("==========Status tests start==========")
+}
+
+// WaitForStatus returns the next StatusEvent of the category received on the channel,
+// false if none is received within the timeout.
+func WaitForStatus(channel chan pubnubMessaging.StatusEvent, category pubnubMessaging.StatusCategory, timeout time.Duration) (pubnubMessaging.StatusEvent, bool) {
+    timer := time.After(timeout)
+    for {
+        select {
+            case event := <-channel:
+                if(event.Category == category){
+                    return event, true
+                }
+            case <-timer:
+                return pubnubMessaging.StatusEvent{}, false
+        }
+    }
+}
+
+// TestStatusEvents subscribes to a channel, subscribes to it again and unsubscribes.
+// The Connected, AlreadySubscribed and Unsubscribed events should be sent on the status channel.
+func TestStatusEvents(t *testing.T){
+    server := NewSubscribeServer("[[],\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+    statusChannel := make(chan pubnubMessaging.StatusEvent, 10)
+    pubnubInstance.SetStatusChannel(statusChannel)
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    event, ok := WaitForStatus(statusChannel, pubnubMessaging.StatusConnected, 5 * time.Second)
+    if(!ok || (len(event.Channels) != 1) || (event.Channels[0] != "ch1") || (len(event.PresenceChannels) != 0)){
+        t.Fatal("Test 'StatusEvents': failed. Connected: ", event)
+    }
+
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    event, ok = WaitForStatus(statusChannel, pubnubMessaging.StatusAlreadySubscribed, 5 * time.Second)
+    if(!ok || (len(event.Channels) != 1) || (event.Channels[0] != "ch1")){
+        t.Fatal("Test 'StatusEvents': failed. AlreadySubscribed: ", event)
+    }
+
+    go pubnubInstance.Unsubscribe("ch1", returnChannel, errorChannel)
+    event, ok = WaitForStatus(statusChannel, pubnubMessaging.StatusUnsubscribed, 5 * time.Second)
+    if(!ok || (len(event.Channels) != 1) || (event.Channels[0] != "ch1")){
+        t.Fatal("Test 'StatusEvents': failed. Unsubscribed: ", event)
+    }
+    fmt.Println("Test 'StatusEvents': passed.")
+}
+
+// TestStatusChannelNotRead sets a status channel that is never read, the subscribe loop
+// should drop the status events and still deliver the messages.
+func TestStatusChannelNotRead(t *testing.T){
+    server := NewSubscribeServer("[[\"hello\"],\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+    pubnubInstance.SetStatusChannel(make(chan pubnubMessaging.StatusEvent))
+
+    returnChannel := make(chan []byte, 10)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    if(WaitForMessage(returnChannel, "hello", 5 * time.Second)){
+        fmt.Println("Test 'StatusChannelNotRead': passed.")
+    } else {
+        t.Error("Test 'StatusChannelNotRead': failed.")
+    }
+}
+
+// TestCreateStatusEvent splits the channels and the presence channels of a status event.
+func TestCreateStatusEvent(t *testing.T){
+    event := pubnubMessaging.CreateStatusEvent(pubnubMessaging.StatusDisconnected, "ch1,ch2-pnpres", 3)
+    if((len(event.Channels) == 1) && (event.Channels[0] == "ch1") && (len(event.PresenceChannels) == 1) &&
+        (event.PresenceChannels[0] == "ch2") && (event.RetryCount == 3)){
+        fmt.Println("Test 'CreateStatusEvent': passed.")
+    } else {
+        t.Error("Test 'CreateStatusEvent': failed.", event)
+    }
+}
+
+// TestCreateStatusResponse checks the json response of the status events
+// sent on the callback and error channels.
+func TestCreateStatusResponse(t *testing.T){
+    connected := pubnubMessaging.CreateStatusResponse(pubnubMessaging.StatusConnected, "ch1-pnpres", 0)
+    disconnected := pubnubMessaging.CreateStatusResponse(pubnubMessaging.StatusDisconnected, "ch1", 2)
+    if((connected == "[1, \"Presence notifications for channel 'ch1' connected\", \"ch1\"]") &&
+        (disconnected == "[0, \"Subscription to channel disconnected due to internet connection issues, trying to reconnect. Retry count:2\", \"ch1\"]")){
+        fmt.Println("Test 'CreateStatusResponse': passed.")
+    } else {
+        t.Error("Test 'CreateStatusResponse': failed.", connected, disconnected)
+    }
+}
+
+// TestSendResponseToChannel sends the disconnected action with the retry count in the response,
+// the status response should be sent on the channel.
+func TestSendResponseToChannel(t *testing.T){
+    pubnubInstance := pubnubMessaging.PubnubInit("demo", "demo", "", "", false, "")
+    responseChannel := make(chan []byte, 1)
+    pubnubInstance.SendResponseToChannel(responseChannel, "ch1", 7, "2", "")
+    value := string(<-responseChannel)
+    if(value == "[0, \"Subscription to channel disconnected due to internet connection issues, trying to reconnect. Retry count:2\", \"ch1\"]"){
+        fmt.Println("Test 'SendResponseToChannel': passed.")
+    } else {
+        t.Error("Test 'SendResponseToChannel': failed.", value)
+    }
+}
+
+// TestStatusEnd prints a message on the screen to mark the end of
+// status tests.
+// PrintTestMessage is defined in the common.go file.
+func TestStatusEnd(t *testing.T){
+    PrintTestMessage("==========Status tests end==========")
+}
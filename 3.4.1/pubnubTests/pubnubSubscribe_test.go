@@ -679,13 +679,13 @@ func ParseSubscribeForTimetoken(pubnubInstance *pubnubMessaging.Pubnub, pubnubCh
                 }
                 if string(value) != "[]"{
                     if(testName == "ResumeOnReconnectTrue"){
-                        if(pubnubInstance.SentTimeToken == pubnubInstance.TimeToken) {
+                        if(pubnubInstance.SentTimeToken() == pubnubInstance.TimeToken()) {
                             responseChannel <- "passed"
                         } else {
                             responseChannel <- "failed"
                         }
                     } else {
-                        if(pubnubInstance.SentTimeToken != "0") {
+                        if(pubnubInstance.SentTimeToken() != "0") {
                             responseChannel <- "failed"
                         } else {
                             responseChannel <- "passed"
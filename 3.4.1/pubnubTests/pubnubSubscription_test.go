@@ -0,0 +1,166 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubSubscription_test.go contains the tests related to the subscribed channels
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "sync"
+    "time"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestSubscriptionStart prints a message on the screen to mark the beginning of
+// subscription tests.
+// PrintTestMessage is defined in the common.go file.
+func TestSubscriptionStart(t *testing.T){
+    PrintTestMessage("==========Subscription tests start==========")
+}
+
+// TestConcurrentSubscribeUnsubscribe subscribes to and unsubscribes from channels from many
+// goroutines while the subscribe loop runs. Run with -race to check the synchronization.
+// Each channel should be subscribed once and only the channels not unsubscribed should remain.
+func TestConcurrentSubscribeUnsubscribe(t *testing.T){
+    server := NewSubscribeServer("[[],\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+
+    var wg sync.WaitGroup
+    for i := 0; i < 20; i++ {
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            channel := fmt.Sprintf("ch%d", i)
+            pubnubInstance.Subscribe(channel, "", returnChannel, false, errorChannel)
+            pubnubInstance.Subscribe(channel, "", returnChannel, true, errorChannel)
+            time.Sleep(10 * time.Millisecond)
+            if(i % 2 == 0){
+                pubnubInstance.Unsubscribe(channel, returnChannel, errorChannel)
+            }
+            _ = pubnubInstance.TimeToken()
+        }(i)
+    }
+    wg.Wait()
+
+    subscribed := pubnubInstance.SubscribedChannels()
+    presence := pubnubInstance.PresenceChannels()
+    counts := make(map[string]int)
+    for _, channel := range subscribed {
+        counts[channel]++
+    }
+    for _, channel := range presence {
+        counts[channel + "-pnpres"]++
+    }
+    passed := (len(subscribed) == 10) && (len(presence) == 20)
+    for i := 0; i < 20; i++ {
+        channel := fmt.Sprintf("ch%d", i)
+        expected := 1
+        if(i % 2 == 0){
+            expected = 0
+        }
+        if((counts[channel] != expected) || (counts[channel + "-pnpres"] != 1)){
+            passed = false
+        }
+    }
+    if(passed){
+        fmt.Println("Test 'ConcurrentSubscribeUnsubscribe': passed.")
+    } else {
+        t.Error("Test 'ConcurrentSubscribeUnsubscribe': failed.", subscribed, presence)
+    }
+}
+
+// TestChannelSet adds channels with whitespace, trailing commas and duplicates to a ChannelSet
+// and removes one, the order of the other channels should be kept.
+func TestChannelSet(t *testing.T){
+    set := pubnubMessaging.NewChannelSet(" ch1, ch2,,ch1 ,")
+    added := set.Add("ch3 ")
+    duplicate := set.Add(" ch2")
+    removed := set.Remove("ch1")
+    channels := set.Channels()
+    if(added && !duplicate && removed && !set.Contains("ch1") && set.Contains(" ch3") && (set.Len() == 2) &&
+        (channels[0] == "ch2") && (channels[1] == "ch3") && (set.Join("-pnpres") == "ch2-pnpres,ch3-pnpres")){
+        fmt.Println("Test 'ChannelSet': passed.")
+    } else {
+        t.Error("Test 'ChannelSet': failed.", channels)
+    }
+}
+
+// TestSubscribeChannelsWithWhitespace subscribes to a channel twice with whitespace around the name
+// and to its presence, the channel should be subscribed once and the presence tracked separately.
+func TestSubscribeChannelsWithWhitespace(t *testing.T){
+    server := NewSubscribeServer("[[],\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    pubnubInstance.Subscribe("ch1 , ch2", "", returnChannel, false, errorChannel)
+    pubnubInstance.Subscribe(" ch1", "", returnChannel, false, errorChannel)
+    pubnubInstance.Subscribe("ch1", "", returnChannel, true, errorChannel)
+    subscribed := pubnubInstance.SubscribedChannels()
+    presence := pubnubInstance.PresenceChannels()
+    if((len(subscribed) == 2) && (subscribed[0] == "ch1") && (subscribed[1] == "ch2") &&
+        (len(presence) == 1) && (presence[0] == "ch1")){
+        fmt.Println("Test 'SubscribeChannelsWithWhitespace': passed.")
+    } else {
+        t.Error("Test 'SubscribeChannelsWithWhitespace': failed.", subscribed, presence)
+    }
+}
+
+// TestGetSubscribedChannels compares channels to the subscribed ones, the new channels should be
+// appended without being subscribed and the subscribed one sent an AlreadySubscribed response.
+func TestGetSubscribedChannels(t *testing.T){
+    server := NewSubscribeServer("[[],\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte, 10)
+    go DrainResponse(returnChannel)
+    pubnubInstance.Subscribe("ch1", "", returnChannel, false, errorChannel)
+    subChannels, newSubChannels, modified := pubnubInstance.GetSubscribedChannels("ch1,ch2", nil, false, errorChannel)
+    subscribed := pubnubInstance.SubscribedChannels()
+    if((subChannels == "ch1,ch2") && (newSubChannels == "ch2") && modified && (len(subscribed) == 1) &&
+        WaitForMessage(errorChannel, "already subscribed", 3 * time.Second)){
+        fmt.Println("Test 'GetSubscribedChannels': passed.")
+    } else {
+        t.Error("Test 'GetSubscribedChannels': failed.", subChannels, newSubChannels, modified, subscribed)
+    }
+}
+
+// TestNotDuplicate subscribes to the presence of a channel, only the channel suffixed with
+// "-pnpres" should be a duplicate.
+func TestNotDuplicate(t *testing.T){
+    server := NewSubscribeServer("[[],\"13796254500000001\"]")
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    defer pubnubInstance.Abort()
+
+    returnChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(returnChannel)
+    go DrainResponse(errorChannel)
+    pubnubInstance.Subscribe("ch1", "", returnChannel, true, errorChannel)
+    if(!pubnubInstance.NotDuplicate("ch1-pnpres") && pubnubInstance.NotDuplicate("ch1")){
+        fmt.Println("Test 'NotDuplicate': passed.")
+    } else {
+        t.Error("Test 'NotDuplicate': failed.")
+    }
+}
+
+// TestSubscriptionEnd prints a message on the screen to mark the end of
+// subscription tests.
+// PrintTestMessage is defined in the common.go file.
+func TestSubscriptionEnd(t *testing.T){
+    PrintTestMessage("==========Subscription tests end==========")
+}
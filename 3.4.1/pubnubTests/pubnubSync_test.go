@@ -0,0 +1,120 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubSync_test.go contains the tests related to the blocking requests with typed responses
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "strings"
+    "net/http"
+    "net/http/httptest"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestSyncStart prints a message on the screen to mark the beginning of 
+// sync tests.
+// PrintTestMessage is defined in the common.go file.
+func TestSyncStart(t *testing.T){
+    PrintTestMessage("==========Sync tests start==========")
+}
+
+// NewSyncServer starts a local server that answers the publish, history, herenow and time 
+// requests with canned responses.
+func NewSyncServer() *httptest.Server {
+    return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        switch {
+            case strings.HasPrefix(r.URL.Path, "/publish/demo/demo/0/forbidden/"):
+                w.WriteHeader(403)
+                fmt.Fprint(w, "[0,\"Forbidden\"]")
+            case strings.HasPrefix(r.URL.Path, "/publish/"):
+                fmt.Fprint(w, "[1,\"Sent\",\"13796254500000001\"]")
+            case strings.HasPrefix(r.URL.Path, "/v2/history/"):
+                fmt.Fprint(w, "[[\"first\",{\"text\":\"second\"}],13796254500000001,13796254500000003]")
+            case strings.HasPrefix(r.URL.Path, "/v2/presence/"):
+                fmt.Fprint(w, "{\"uuids\":[\"uuid1\",\"uuid2\"],\"occupancy\":2}")
+            case strings.HasPrefix(r.URL.Path, "/time/"):
+                fmt.Fprint(w, "[13796254500000009]")
+        }
+    }))
+}
+
+// TestPublishSync checks the typed publish result and the error on a non 200 response.
+func TestPublishSync(t *testing.T){
+    server := NewSyncServer()
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    
+    result, err := pubnubInstance.PublishSync("testChannel", "message")
+    if((err != nil) || (result.Timetoken != 13796254500000001)){
+        t.Error("Test 'PublishSync': failed.", result, err)
+    } else if _, err := pubnubInstance.PublishSync("forbidden", "message"); (err == nil) || !strings.Contains(err.Error(), "Forbidden"){
+        t.Error("Test 'PublishSync': failed. Error: ", err)
+    } else if _, err := pubnubInstance.PublishSync("testChannel", nil); err == nil {
+        t.Error("Test 'PublishSync': failed. Invalid message accepted.")
+    } else {
+        fmt.Println("Test 'PublishSync': passed.")
+    }
+}
+
+// TestHistorySync checks the typed history result.
+func TestHistorySync(t *testing.T){
+    server := NewSyncServer()
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    
+    result, err := pubnubInstance.HistorySync("testChannel", 10, 0, 0, false)
+    if((err != nil) || (len(result.Messages) != 2) || (result.StartTimetoken != 13796254500000001) || (result.EndTimetoken != 13796254500000003)){
+        t.Error("Test 'HistorySync': failed.", result, err)
+    } else if(result.Messages[0] != "first"){
+        t.Error("Test 'HistorySync': failed.", result.Messages[0])
+    } else {
+        fmt.Println("Test 'HistorySync': passed.")
+    }
+}
+
+// TestHereNowSync checks the typed herenow result.
+func TestHereNowSync(t *testing.T){
+    server := NewSyncServer()
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    
+    result, err := pubnubInstance.HereNowSync("testChannel")
+    if((err != nil) || (result.Occupancy != 2) || (len(result.Uuids) != 2)){
+        t.Error("Test 'HereNowSync': failed.", result, err)
+    } else {
+        fmt.Println("Test 'HereNowSync': passed.")
+    }
+}
+
+// TestParseJsonObject parses an object response like the herenow response,
+// the object should be returned as the data.
+func TestParseJsonObject(t *testing.T){
+    value := "{\"uuids\":[\"uuid1\"],\"occupancy\":1}"
+    data, _, _, err := pubnubMessaging.ParseJson([]byte(value), "")
+    if((err == nil) && (data == value)){
+        fmt.Println("Test 'ParseJsonObject': passed.")
+    } else {
+        t.Error("Test 'ParseJsonObject': failed.", data, err)
+    }
+}
+
+// TestTimeSync checks the typed time result.
+func TestTimeSync(t *testing.T){
+    server := NewSyncServer()
+    defer server.Close()
+    pubnubInstance := InitWithMockOrigin(server, "", "")
+    
+    timetoken, err := pubnubInstance.Time()
+    if((err != nil) || (timetoken != 13796254500000009)){
+        t.Error("Test 'TimeSync': failed.", timetoken, err)
+    } else {
+        fmt.Println("Test 'TimeSync': passed.")
+    }
+}
+
+// TestSyncEnd prints a message on the screen to mark the end of 
+// sync tests.
+// PrintTestMessage is defined in the common.go file.
+func TestSyncEnd(t *testing.T){
+    PrintTestMessage("==========Sync tests end==========")
+}
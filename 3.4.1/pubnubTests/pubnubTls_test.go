@@ -0,0 +1,85 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubTls_test.go contains the tests related to the TLS verification of the ssl connections
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "time"
+    "net/http"
+    "net/http/httptest"
+    "crypto/x509"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestTlsStart prints a message on the screen to mark the beginning of 
+// TLS tests.
+// PrintTestMessage is defined in the common.go file.
+func TestTlsStart(t *testing.T){
+    PrintTestMessage("==========TLS tests start==========")
+}
+
+// WithTls returns the configure func of InitWithConfig that enables ssl with the root CA pool
+// and the public key pins.
+func WithTls(rootCAs *x509.CertPool, pins []string) func(*pubnubMessaging.Config) {
+    return func(config *pubnubMessaging.Config){
+        config.Ssl = true
+        config.RootCAs = rootCAs
+        config.PinnedPublicKeys = pins
+    }
+}
+
+// RunTlsTimeTest sends a time request to the local TLS server and checks 
+// if the response is received or the error is a TlsError.
+func RunTlsTimeTest(t *testing.T, pubnubInstance *pubnubMessaging.Pubnub, expectSuccess bool, testName string){
+    returnTimeChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go pubnubInstance.GetTime(returnTimeChannel, errorChannel)
+    select {
+        case value := <-returnTimeChannel:
+            if(expectSuccess){
+                fmt.Println("Test '" + testName + "': passed.")
+            } else {
+                t.Error("Test '" + testName + "': failed. Response: " + string(value))
+            }
+        case value := <-errorChannel:
+            tlsError := false
+            pubnubError, err := pubnubMessaging.DecodeErrorResponse(value)
+            if(err == nil){
+                _, tlsError = pubnubError.Err.(*pubnubMessaging.TlsError)
+            }
+            if(!expectSuccess && tlsError &&
+                (pubnubMessaging.ErrorCategory(pubnubError.Err) == pubnubMessaging.ErrorCategoryTls)){
+                fmt.Println("Test '" + testName + "': passed.")
+            } else {
+                t.Error("Test '" + testName + "': failed. Error: " + string(value))
+            }
+        case <-time.After(10 * time.Second):
+            t.Error("Test '" + testName + "': failed. " + _timeoutMessage)
+    }
+}
+
+// TestTlsVerification checks the certificate verification with the system pool, 
+// a custom root CA pool and the public key pins.
+func TestTlsVerification(t *testing.T){
+    server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[13796254500000000]")
+    }))
+    defer server.Close()
+    
+    rootCAs := x509.NewCertPool()
+    rootCAs.AddCert(server.Certificate())
+    pin := pubnubMessaging.PublicKeyPin(server.Certificate())
+    
+    RunTlsTimeTest(t, InitWithConfig(server, "", WithTls(nil, nil)), false, "TlsUnknownAuthority")
+    RunTlsTimeTest(t, InitWithConfig(server, "", WithTls(rootCAs, nil)), true, "TlsCustomRootCAs")
+    RunTlsTimeTest(t, InitWithConfig(server, "", WithTls(rootCAs, []string{pin})), true, "TlsPinMatch")
+    RunTlsTimeTest(t, InitWithConfig(server, "", WithTls(rootCAs, []string{"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="})), false, "TlsPinMismatch")
+}
+
+// TestTlsEnd prints a message on the screen to mark the end of 
+// TLS tests.
+// PrintTestMessage is defined in the common.go file.
+func TestTlsEnd(t *testing.T){
+    PrintTestMessage("==========TLS tests end==========")
+}
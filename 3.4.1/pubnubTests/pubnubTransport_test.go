@@ -0,0 +1,257 @@
+// Package pubnubMessaging has the unit tests of package pubnubMessaging.
+// pubnubTransport_test.go contains the tests related to the per instance transports and connections
+package pubnubTests
+
+import (
+    "testing"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "io/ioutil"
+    "bytes"
+    "github.com/pubnub/go/3.4.1/pubnubMessaging"
+)
+
+// TestTransportStart prints a message on the screen to mark the beginning of 
+// transport tests.
+// PrintTestMessage is defined in the common.go file.
+func TestTransportStart(t *testing.T){
+    PrintTestMessage("==========Transport tests start==========")
+}
+
+// TestCloseConnectionPerInstance subscribes two instances to a local origin and closes the 
+// subscribe connection of the first one. Only the long-poll of the first instance should be cancelled.
+func TestCloseConnectionPerInstance(t *testing.T){
+    pendingUuids := make(chan string, 10)
+    closedUuids := make(chan string, 10)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        if(strings.HasPrefix(r.URL.Path, "/subscribe/")){
+            if(strings.HasSuffix(r.URL.Path, "/0/0")){
+                fmt.Fprint(w, "[[],\"13796254500000000\"]")
+                return
+            }
+            uuid := r.URL.Query().Get("uuid")
+            pendingUuids <- uuid
+            <-r.Context().Done()
+            closedUuids <- uuid
+            return
+        }
+        fmt.Fprint(w, "{\"status\": 200, \"action\": \"leave\"}")
+    }))
+    defer server.Close()
+    
+    pubnubInstance1 := InitWithMockOrigin(server, "", "uuid1")
+    pubnubInstance2 := InitWithMockOrigin(server, "", "uuid2")
+    
+    returnSubscribeChannel1 := make(chan []byte)
+    returnSubscribeChannel2 := make(chan []byte)
+    errorChannel1 := make(chan []byte)
+    errorChannel2 := make(chan []byte)
+    go DrainResponse(errorChannel1)
+    go DrainResponse(errorChannel2)
+    
+    go pubnubInstance1.Subscribe("testChannel", "", returnSubscribeChannel1, false, errorChannel1)
+    go pubnubInstance2.Subscribe("testChannel", "", returnSubscribeChannel2, false, errorChannel2)
+    if(!WaitForMessage(returnSubscribeChannel1, "connected", 5 * time.Second) || !WaitForMessage(returnSubscribeChannel2, "connected", 5 * time.Second)){
+        t.Fatal("Test 'CloseConnectionPerInstance': failed. Not connected.")
+    }
+    go DrainResponse(returnSubscribeChannel1)
+    go DrainResponse(returnSubscribeChannel2)
+    for i := 0; i < 2; i++ {
+        select {
+            case <-pendingUuids:
+            case <-time.After(5 * time.Second):
+                t.Fatal("Test 'CloseConnectionPerInstance': failed. Long-poll not started.")
+        }
+    }
+    
+    pubnubInstance1.CloseExistingConnection()
+    select {
+        case uuid := <-closedUuids:
+            if(uuid != "uuid1"){
+                t.Error("Test 'CloseConnectionPerInstance': failed. Closed the connection of " + uuid)
+            }
+        case <-time.After(5 * time.Second):
+            t.Error("Test 'CloseConnectionPerInstance': failed. Connection not closed.")
+    }
+    select {
+        case uuid := <-closedUuids:
+            if(uuid == "uuid2"){
+                t.Error("Test 'CloseConnectionPerInstance': failed. Closed the connection of " + uuid)
+            }
+        case <-time.After(1 * time.Second):
+            fmt.Println("Test 'CloseConnectionPerInstance': passed.")
+    }
+    pubnubInstance1.Abort()
+    pubnubInstance2.Abort()
+}
+
+// RecordingTransport is a http.RoundTripper that records the requested urls
+// and answers every request with the Response body, without any network access.
+type RecordingTransport struct {
+    Requests chan string
+    Response string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error){
+    transport.Requests <- req.URL.String()
+    return &http.Response{
+        StatusCode: 200,
+        Header: make(http.Header),
+        Body: ioutil.NopCloser(bytes.NewBufferString(transport.Response)),
+        Request: req,
+    }, nil
+}
+
+// TestCustomTransport sends a time request through a user provided http.RoundTripper.
+func TestCustomTransport(t *testing.T){
+    transport := &RecordingTransport{Requests: make(chan string, 1), Response: "[13796254500000000]"}
+    config := pubnubMessaging.DefaultConfig()
+    config.Transport = transport
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    
+    returnTimeChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go pubnubInstance.GetTime(returnTimeChannel, errorChannel)
+    go DrainResponse(errorChannel)
+    
+    if(!WaitForMessage(returnTimeChannel, "13796254500000000", 5 * time.Second)){
+        t.Error("Test 'CustomTransport': failed. Response not received.")
+    } else if requestUrl := <-transport.Requests; !strings.HasSuffix(requestUrl, "/time/0"){
+        t.Error("Test 'CustomTransport': failed. Url: " + requestUrl)
+    } else {
+        fmt.Println("Test 'CustomTransport': passed.")
+    }
+}
+
+// TestCustomHttpClientCancel subscribes using a user provided http.Client and 
+// checks that CloseExistingConnection still cancels the long-poll.
+func TestCustomHttpClientCancel(t *testing.T){
+    pending := make(chan bool, 10)
+    closed := make(chan bool, 10)
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        if(strings.HasPrefix(r.URL.Path, "/subscribe/")){
+            if(strings.HasSuffix(r.URL.Path, "/0/0")){
+                fmt.Fprint(w, "[[],\"13796254500000000\"]")
+                return
+            }
+            pending <- true
+            <-r.Context().Done()
+            closed <- true
+            return
+        }
+        fmt.Fprint(w, "{\"status\": 200, \"action\": \"leave\"}")
+    }))
+    defer server.Close()
+    
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = strings.TrimPrefix(server.URL, "http://")
+    config.HttpClient = server.Client()
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    
+    returnSubscribeChannel := make(chan []byte)
+    errorChannel := make(chan []byte)
+    go DrainResponse(errorChannel)
+    go pubnubInstance.Subscribe("testChannel", "", returnSubscribeChannel, false, errorChannel)
+    if(!WaitForMessage(returnSubscribeChannel, "connected", 5 * time.Second)){
+        t.Fatal("Test 'CustomHttpClientCancel': failed. Not connected.")
+    }
+    go DrainResponse(returnSubscribeChannel)
+    <-pending
+    pubnubInstance.CloseExistingConnection()
+    select {
+        case <-closed:
+            fmt.Println("Test 'CustomHttpClientCancel': passed.")
+        case <-time.After(5 * time.Second):
+            t.Error("Test 'CustomHttpClientCancel': failed. Request not cancelled.")
+    }
+    pubnubInstance.Abort()
+}
+
+// TestKeepAliveReuse publishes many messages and publishes again after the non subscribe timeout,
+// all the requests should reuse a single keep-alive connection.
+func TestKeepAliveReuse(t *testing.T){
+    var lock sync.Mutex
+    connections := 0
+    server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[1,\"Sent\",\"13796254500000001\"]")
+    }))
+    server.Config.ConnState = func(conn net.Conn, state http.ConnState){
+        if(state == http.StateNew){
+            lock.Lock()
+            connections++
+            lock.Unlock()
+        }
+    }
+    server.Start()
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = strings.TrimPrefix(server.URL, "http://")
+    config.NonSubscribeTimeout = 1
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    defer pubnubInstance.Abort()
+
+    for i := 0; i < 50; i++ {
+        if _, err := pubnubInstance.PublishSync("testChannel", i); err != nil {
+            t.Fatal("Test 'KeepAliveReuse': failed.", err)
+        }
+    }
+    time.Sleep(1500 * time.Millisecond)
+    _, err := pubnubInstance.PublishSync("testChannel", "after the timeout")
+    lock.Lock()
+    defer lock.Unlock()
+    if((err == nil) && (connections == 1)){
+        fmt.Println("Test 'KeepAliveReuse': passed.")
+    } else {
+        t.Error("Test 'KeepAliveReuse': failed.", err, connections)
+    }
+}
+
+// TestResponseHeaderTimeout requests the time from a server that doesn't respond with a 
+// non subscribe timeout of 1 second, the request should fail with a TimeoutError.
+func TestResponseHeaderTimeout(t *testing.T){
+    server := NewHangingServer()
+    defer server.Close()
+    config := pubnubMessaging.DefaultConfig()
+    config.Origin = strings.TrimPrefix(server.URL, "http://")
+    config.NonSubscribeTimeout = 1
+    config.RequestRetryPolicy = pubnubMessaging.NewLinearRetryPolicy(0, 0)
+    pubnubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", "", "", config)
+    defer pubnubInstance.Abort()
+
+    start := time.Now()
+    _, err := pubnubInstance.Time()
+    if _, ok := err.(*pubnubMessaging.TimeoutError); ok && (time.Since(start) < 3 * time.Second) {
+        fmt.Println("Test 'ResponseHeaderTimeout': passed.")
+    } else {
+        t.Error("Test 'ResponseHeaderTimeout': failed.", err, time.Since(start))
+    }
+}
+
+// TestPackageConnect requests a local server with the package-level Connect, the response
+// should be returned by the package's default instance.
+func TestPackageConnect(t *testing.T){
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request){
+        fmt.Fprint(w, "[13796254500000000]")
+    }))
+    defer server.Close()
+
+    value, statusCode, err := pubnubMessaging.Connect(server.URL + "/time/0", false)
+    if((err == nil) && (statusCode == 200) && (string(value) == "[13796254500000000]")){
+        fmt.Println("Test 'PackageConnect': passed.")
+    } else {
+        t.Error("Test 'PackageConnect': failed.", string(value), statusCode, err)
+    }
+}
+
+// TestTransportEnd prints a message on the screen to mark the end of 
+// transport tests.
+// PrintTestMessage is defined in the common.go file.
+func TestTransportEnd(t *testing.T){
+    PrintTestMessage("==========Transport tests end==========")
+}
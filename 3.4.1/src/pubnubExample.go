@@ -67,6 +67,7 @@ func Init() (b bool){
         _connectChannels = string(line)
         if strings.TrimSpace(_connectChannels) != "" { 
             fmt.Println("Channel: ", _connectChannels)
+            config := pubnubMessaging.DefaultConfig()
             fmt.Println("Enable SSL? Enter y for Yes, n for No.")
             var enableSsl string
             fmt.Scanln(&enableSsl)
@@ -78,6 +79,7 @@ func Init() (b bool){
                 _ssl = false
                 fmt.Println("SSL disabled")
             }
+            config.Ssl = _ssl
             
             fmt.Println("Please enter a CIPHER key, leave blank if you don't want to use this.")
             fmt.Scanln(&_cipher)
@@ -104,10 +106,10 @@ func Init() (b bool){
             fmt.Scanln(&enableResumeOnReconnect)
             
             if enableResumeOnReconnect == "y" || enableResumeOnReconnect == "Y" {
-                pubnubMessaging.SetResumeOnReconnect(true)
+                config.ResumeOnReconnect = true
                 fmt.Println("Resume on reconnect enabled")    
             }else{
-                pubnubMessaging.SetResumeOnReconnect(false)
+                config.ResumeOnReconnect = false
                 fmt.Println("Resume on reconnect disabled")
             }
             
@@ -118,14 +120,15 @@ func Init() (b bool){
             if (err != nil) {
                 fmt.Println("Entered value is invalid. Using default value.")
             } else {
-                pubnubMessaging.SetSubscribeTimeout(int64(val))
+                config.SubscribeTimeout = int64(val)
             }
-            pubnubMessaging.SetOrigin ("pubsub.pubnub.com")
-            pubInstance := pubnubMessaging.PubnubInit("demo", "demo", "", _cipher, _ssl, _uuid)
+            config.Origin = "pubsub.pubnub.com"
             
-            _pub = pubInstance
+            SetupProxy(&config)
+            
+            pubInstance := pubnubMessaging.PubnubInitWithConfig("demo", "demo", "", _cipher, _uuid, config)
             
-            SetupProxy()
+            _pub = pubInstance
             
             return true
         }else{
@@ -135,9 +138,9 @@ func Init() (b bool){
     return false
 }
 
-// SetupProxy asks the user the Proxy details and calls the SetProxy of the pubnubMessaging 
-// package with the details. 
-func SetupProxy(){
+// SetupProxy asks the user the Proxy details and sets them in the pubnubMessaging.Config 
+// used to initialize the pubnub instance. 
+func SetupProxy(config *pubnubMessaging.Config){
     fmt.Println("Using Proxy? Enter y to setup.")
     var enableProxy string
     fmt.Scanln(&enableProxy)
@@ -148,7 +151,10 @@ func SetupProxy(){
         proxyUser := AskUser();
         proxyPassword := AskPassword();
     
-        pubnubMessaging.SetProxy(proxyServer, proxyPort, proxyUser, proxyPassword)
+        config.ProxyServer = proxyServer
+        config.ProxyPort = proxyPort
+        config.ProxyUser = proxyUser
+        config.ProxyPassword = proxyPassword
     
         fmt.Println("Proxy sever set")    
     }else{